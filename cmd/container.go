@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// containerHeaders returns the headers of the --by-container table.
+func (o *Options) containerHeaders() []string {
+	headers := []string{"POD", "CONTAINER", "IMAGE", "CPU-REQ", "CPU-LIM", "MEM-REQ", "MEM-LIM", "RESTARTS", "NODE", "SERVICEACCOUNT"}
+	if o.AllNamespaces {
+		headers = append([]string{"NAMESPACE"}, headers...)
+	}
+	return headers
+}
+
+// containerRows returns one row per init and regular container of the pod.
+// Init containers come first and are marked with an "init:" prefix.
+func (o *Options) containerRows(pn PodWithWider) [][]string {
+	pod := pn.Pod
+
+	var rows [][]string
+	add := func(name string, c corev1.Container, statuses []corev1.ContainerStatus) {
+		restarts := 0
+		for _, cs := range statuses {
+			if cs.Name == c.Name {
+				restarts = int(cs.RestartCount)
+			}
+		}
+
+		row := []string{
+			pod.Name,
+			name,
+			c.Image,
+			formatQuantity(c.Resources.Requests, corev1.ResourceCPU),
+			formatQuantity(c.Resources.Limits, corev1.ResourceCPU),
+			formatQuantity(c.Resources.Requests, corev1.ResourceMemory),
+			formatQuantity(c.Resources.Limits, corev1.ResourceMemory),
+			strconv.Itoa(restarts),
+			valueOrNone(pod.Spec.NodeName),
+			valueOrNone(pod.Spec.ServiceAccountName),
+		}
+		if o.AllNamespaces {
+			row = append([]string{pod.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		add("init:"+c.Name, c, pod.Status.InitContainerStatuses)
+	}
+	for _, c := range pod.Spec.Containers {
+		add(c.Name, c, pod.Status.ContainerStatuses)
+	}
+	return rows
+}
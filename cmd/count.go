@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+)
+
+// runCount prints the number of pods kept by the filters, with a
+// per-namespace breakdown under -A. No related resources are fetched.
+func (o *Options) runCount(ctx context.Context, ns string) error {
+	pods, err := o.listPods(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	perNamespace := map[string]int{}
+	for i := range pods.Items {
+		if o.keepPod(&pods.Items[i]) {
+			total++
+			perNamespace[pods.Items[i].Namespace]++
+		}
+	}
+
+	if !o.AllNamespaces {
+		fmt.Fprintln(o.Out, total)
+		return nil
+	}
+
+	namespaces := make([]string, 0, len(perNamespace))
+	for namespace := range perNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NAMESPACE\tPODS")
+	}
+	for _, namespace := range namespaces {
+		fmt.Fprintf(w, "%s\t%d\n", namespace, perNamespace[namespace])
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\n", total)
+	return nil
+}
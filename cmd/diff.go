@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// PodDiff is a pod that exists in only one of the --diff contexts, or whose
+// compared fields differ between them.
+type PodDiff struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	OnlyIn    string        `json:"onlyIn,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field whose value differs between the --diff contexts.
+type FieldChange struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// diffFields are the fields compared for pods present in both contexts.
+var diffFields = []struct {
+	name  string
+	value func(PodWithWider) string
+}{
+	{"node", func(pn PodWithWider) string { return pn.Pod.Spec.NodeName }},
+	{"image", func(pn PodWithWider) string {
+		var images []string
+		for _, c := range pn.Pod.Spec.Containers {
+			images = append(images, c.Image)
+		}
+		return strings.Join(images, ",")
+	}},
+	{"phase", func(pn PodWithWider) string { return string(pn.Pod.Status.Phase) }},
+}
+
+// runDiff collects the pods of both contexts through the usual pipeline
+// and prints how they differ.
+func (o *Options) runDiff(ctx context.Context, ns string) error {
+	// Copied before context A resolves --selector-from-pod into -l
+	other := o.contextBOptions()
+
+	podsA, err := o.diffSide(ctx, ns)
+	if err != nil {
+		return fmt.Errorf("context %s: %w", o.contextLabel(), err)
+	}
+	podsB, err := other.diffSide(ctx, ns)
+	if err != nil {
+		return fmt.Errorf("context %s: %w", o.ContextB, err)
+	}
+
+	diffs := diffPods(podsA, podsB, o.contextLabel(), o.ContextB)
+	return o.printDiff(diffs)
+}
+
+// contextBOptions returns a copy of the options querying --context-b.
+func (o *Options) contextBOptions() *Options {
+	other := *o
+	other.Diff = false
+	other.Context = o.ContextB
+	other.Clientset = o.ClientsetB
+	other.Metadata = o.MetadataB
+	other.Dynamic = o.DynamicB
+	return &other
+}
+
+// diffSide checks the namespace, resolves --selector-from-pod and collects
+// the pods of one context of a diff.
+func (o *Options) diffSide(ctx context.Context, ns string) ([]PodWithWider, error) {
+	if err := o.checkNamespace(ctx); err != nil {
+		return nil, err
+	}
+	if o.SelectorFromPod != "" {
+		if err := o.applySelectorFromPod(ctx); err != nil {
+			return nil, err
+		}
+	}
+	podNodes, _, _, err := o.collectPods(ctx, ns)
+	return podNodes, err
+}
+
+// contextLabel names the --context side of a diff.
+func (o *Options) contextLabel() string {
+	if o.Context == "" {
+		return "<current>"
+	}
+	return o.Context
+}
+
+// diffPods matches the pods of a and b by namespace/name and returns those
+// missing from either side or differing in a diffFields field, sorted by
+// namespace and name.
+func diffPods(a, b []PodWithWider, labelA, labelB string) []PodDiff {
+	byKey := func(pods []PodWithWider) map[string]PodWithWider {
+		m := make(map[string]PodWithWider, len(pods))
+		for _, pn := range pods {
+			m[pn.Pod.Namespace+"/"+pn.Pod.Name] = pn
+		}
+		return m
+	}
+	podsA, podsB := byKey(a), byKey(b)
+
+	var diffs []PodDiff
+	for key, pa := range podsA {
+		pb, ok := podsB[key]
+		if !ok {
+			diffs = append(diffs, PodDiff{Namespace: pa.Pod.Namespace, Name: pa.Pod.Name, OnlyIn: labelA})
+			continue
+		}
+		var changes []FieldChange
+		for _, f := range diffFields {
+			if va, vb := f.value(pa), f.value(pb); va != vb {
+				changes = append(changes, FieldChange{Field: f.name, A: va, B: vb})
+			}
+		}
+		if len(changes) > 0 {
+			diffs = append(diffs, PodDiff{Namespace: pa.Pod.Namespace, Name: pa.Pod.Name, Changes: changes})
+		}
+	}
+	for key, pb := range podsB {
+		if _, ok := podsA[key]; !ok {
+			diffs = append(diffs, PodDiff{Namespace: pb.Pod.Namespace, Name: pb.Pod.Name, OnlyIn: labelB})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Namespace != diffs[j].Namespace {
+			return diffs[i].Namespace < diffs[j].Namespace
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// printDiff prints the differences as a table, or as JSON under -o json.
+func (o *Options) printDiff(diffs []PodDiff) error {
+	if o.OutputFormat == "json" {
+		encoder := json.NewEncoder(o.Out)
+		encoder.SetIndent("", "  ")
+		if diffs == nil {
+			diffs = []PodDiff{}
+		}
+		return encoder.Encode(diffs)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Fprintf(o.ErrOut, "No differences between %s and %s\n", o.contextLabel(), o.ContextB)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tDIFFERENCE")
+	}
+	for _, d := range diffs {
+		difference := "only in " + d.OnlyIn
+		if d.OnlyIn == "" {
+			var changes []string
+			for _, c := range d.Changes {
+				changes = append(changes, fmt.Sprintf("%s: %s -> %s", c.Field, valueOrNone(c.A), valueOrNone(c.B)))
+			}
+			difference = strings.Join(changes, "; ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Namespace, d.Name, difference)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const envPrefix = "KUBECTL_WIDER_"
+
+// envBindableFlags are the flags whose defaults can be set from the
+// environment, e.g. KUBECTL_WIDER_OUTPUT=wide. The list is kept short on
+// purpose so a stray variable can't silently change filtering behavior.
+var envBindableFlags = []string{
+	"context",
+	"namespace",
+	"output",
+	"selector",
+}
+
+// envVarName returns the environment variable for a flag name.
+func envVarName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// applyEnvDefaults sets every env-bindable flag that wasn't given on the
+// command line from its environment variable, so explicit flags win over
+// the environment, which wins over built-in defaults.
+func applyEnvDefaults(flags *pflag.FlagSet) error {
+	for _, name := range envBindableFlags {
+		// Subcommands don't define every flag
+		if flags.Lookup(name) == nil || flags.Changed(name) {
+			continue
+		}
+
+		value, ok := os.LookupEnv(envVarName(name))
+		if !ok {
+			continue
+		}
+
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, envVarName(name), err)
+		}
+	}
+	return nil
+}
+
+// outputGiven reports whether -o was given on the command line, rather than
+// defaulted from KUBECTL_WIDER_OUTPUT. Modes printing their own format,
+// such as --count, only reject an explicit -o.
+func (o *Options) outputGiven() bool {
+	return o.OutputFormat != "" && !o.outputFromEnv
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// resourceError records which API resource a failed request was for, so
+// structured error output can report it.
+type resourceError struct {
+	resource string
+	err      error
+}
+
+func (e *resourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *resourceError) Unwrap() error {
+	return e.err
+}
+
+// newResourceError wraps err with a "failed to <verb> <resource>" message.
+func newResourceError(verb, resource string, err error) error {
+	return &resourceError{
+		resource: resource,
+		err:      fmt.Errorf("failed to %s %s: %w", verb, resource, err),
+	}
+}
+
+// outputFormatError reports an unsupported -o value along with the
+// supported ones.
+type outputFormatError struct {
+	format    string
+	supported []string
+}
+
+func (e *outputFormatError) Error() string {
+	return fmt.Sprintf("unsupported output format: %s (supported: %s)", e.format, strings.Join(e.supported, ", "))
+}
+
+// printJSONError writes err as a single JSON object, for consumers parsing
+// -o json output.
+func printJSONError(w io.Writer, err error) {
+	out := struct {
+		Error    string `json:"error"`
+		Resource string `json:"resource,omitempty"`
+	}{
+		Error: err.Error(),
+	}
+
+	var re *resourceError
+	if errors.As(err, &re) {
+		out.Resource = re.resource
+	}
+
+	if encodeErr := json.NewEncoder(w).Encode(out); encodeErr != nil {
+		fmt.Fprintln(w, err)
+	}
+}
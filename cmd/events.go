@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// eventTime returns when the event was last seen, falling back to the
+// fields set by the newer events API and to its creation.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// groupPodEvents groups events by the namespace/name of the pod they are
+// about, most recent first, keeping at most limit per pod. A limit of 0
+// keeps them all.
+func groupPodEvents(events []corev1.Event, limit int) map[string][]corev1.Event {
+	grouped := make(map[string][]corev1.Event)
+	for _, event := range events {
+		if event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		key := event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		grouped[key] = append(grouped[key], event)
+	}
+
+	for key, podEvents := range grouped {
+		sort.SliceStable(podEvents, func(i, j int) bool {
+			return eventTime(&podEvents[i]).After(eventTime(&podEvents[j]))
+		})
+		if limit > 0 && len(podEvents) > limit {
+			grouped[key] = podEvents[:limit]
+		}
+	}
+	return grouped
+}
+
+// formatLatestEvent renders the most recent event as "Reason: message" on
+// a single line.
+func formatLatestEvent(events []corev1.Event) string {
+	if len(events) == 0 {
+		return "<none>"
+	}
+	latest := events[0]
+	return latest.Reason + ": " + strings.Join(strings.Fields(latest.Message), " ")
+}
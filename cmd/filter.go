@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// podPhases are the values accepted by --phase.
+var podPhases = []corev1.PodPhase{corev1.PodRunning, corev1.PodPending, corev1.PodFailed, corev1.PodSucceeded}
+
+// keepPod reports whether a pod passes all client-side filters.
+func (o *Options) keepPod(pod *corev1.Pod) bool {
+	if o.podIPFilter != nil && !matchesPodIP(pod, o.podIPFilter) {
+		return false
+	}
+	if o.minAge != nil || o.maxAge != nil {
+		if !matchesAge(pod, o.minAge, o.maxAge, time.Now()) {
+			return false
+		}
+	}
+	// Also checked client-side in case the server ignored the field selector
+	if o.Phase != "" && pod.Status.Phase != corev1.PodPhase(o.Phase) {
+		return false
+	}
+	if o.Ready && !isPodReady(pod) {
+		return false
+	}
+	if o.NotReady && isPodReady(pod) {
+		return false
+	}
+	if o.Terminating && pod.DeletionTimestamp == nil {
+		return false
+	}
+	if o.excludeSelector != nil && o.excludeSelector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+	return true
+}
+
+// instanceLabels are set by controllers per revision or per pod, so
+// --selector-from-pod leaves them out unless --selector-labels asks for them.
+var instanceLabels = []string{
+	"pod-template-hash",
+	"controller-revision-hash",
+	"statefulset.kubernetes.io/pod-name",
+	"apps.kubernetes.io/pod-index",
+	"controller-uid",
+	"batch.kubernetes.io/controller-uid",
+}
+
+// applySelectorFromPod gets the --selector-from-pod pod from the namespace
+// and adds a selector for its labels to -l.
+func (o *Options) applySelectorFromPod(ctx context.Context) error {
+	pod, err := withRetry(ctx, o.MaxRetries, func() (*corev1.Pod, error) {
+		return o.Clientset.CoreV1().Pods(o.Namespace).Get(ctx, o.SelectorFromPod, metav1.GetOptions{})
+	})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("reference pod %q not found in namespace %q", o.SelectorFromPod, o.Namespace)
+	}
+	if err != nil {
+		return newResourceError("get", "pods", err)
+	}
+
+	selector, err := selectorFromLabels(pod.Labels, o.SelectorLabels)
+	if err != nil {
+		return fmt.Errorf("can't select pods like %q: %w", o.SelectorFromPod, err)
+	}
+	if o.LabelSelector != "" {
+		selector = o.LabelSelector + "," + selector
+	}
+	klog.V(logSelectors).Infof("selector from pod %s: %s", o.SelectorFromPod, selector)
+	o.LabelSelector = selector
+	return nil
+}
+
+// selectorFromLabels returns a selector matching podLabels, restricted to
+// keys if any are given and without instanceLabels otherwise.
+func selectorFromLabels(podLabels map[string]string, keys []string) (string, error) {
+	set := labels.Set{}
+	if len(keys) > 0 {
+		for _, key := range keys {
+			value, ok := podLabels[key]
+			if !ok {
+				return "", fmt.Errorf("the pod has no label %q", key)
+			}
+			set[key] = value
+		}
+	} else {
+		for key, value := range podLabels {
+			if !slices.Contains(instanceLabels, key) {
+				set[key] = value
+			}
+		}
+	}
+
+	// An empty selector would match every pod
+	if len(set) == 0 {
+		return "", fmt.Errorf("the pod has no labels to select on")
+	}
+	return labels.SelectorFromSet(set).String(), nil
+}
+
+// podFieldSelector returns the server-side field selector for the
+// status filters, or an empty string if there is none.
+func (o *Options) podFieldSelector() string {
+	if o.Phase == "" {
+		return ""
+	}
+	return "status.phase=" + o.Phase
+}
+
+// isPodReady reports whether the pod's Ready condition is True.
+func isPodReady(pod *corev1.Pod) bool {
+	cond := podReadyCondition(pod)
+	return cond != nil && cond.Status == corev1.ConditionTrue
+}
+
+// podReadyCondition returns the pod's Ready condition, or nil if it has
+// none yet, e.g. while Pending.
+func podReadyCondition(pod *corev1.Pod) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodReady {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// parseAge parses a Go duration such as "90m" or "1h30m", optionally
+// preceded by a whole number of days, as kubectl prints ages: "7d", "2d3h"
+// or "1d12h30m".
+func parseAge(age string) (time.Duration, error) {
+	var d time.Duration
+	days, rest, hasDays := strings.Cut(age, "d")
+	if hasDays {
+		n, err := strconv.Atoi(days)
+		// A sign only belongs in front of the days
+		if err != nil || strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+			return 0, fmt.Errorf("invalid age: %s", age)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		rest = age
+	}
+	if rest != "" || !hasDays {
+		remainder, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age: %s", age)
+		}
+		d += remainder
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("age must not be negative: %s", age)
+	}
+	return d, nil
+}
+
+// matchesAge reports whether the pod's age at now is within minAge and
+// maxAge. Either bound may be nil.
+func matchesAge(pod *corev1.Pod, minAge, maxAge *time.Duration, now time.Time) bool {
+	age := now.Sub(pod.CreationTimestamp.Time)
+
+	if minAge != nil && age < *minAge {
+		return false
+	}
+	if maxAge != nil && age > *maxAge {
+		return false
+	}
+	return true
+}
+
+// podIPFilter is a parsed --pod-ip: either a CIDR or a single IP address.
+type podIPFilter struct {
+	cidr *net.IPNet
+	ip   net.IP
+}
+
+// parsePodIPFilter accepts a single IP address or a CIDR.
+func parsePodIPFilter(filter string) (*podIPFilter, error) {
+	if strings.Contains(filter, "/") {
+		_, cidr, err := net.ParseCIDR(filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --pod-ip CIDR: %s", filter)
+		}
+		return &podIPFilter{cidr: cidr}, nil
+	}
+
+	ip := net.ParseIP(filter)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid --pod-ip address: %s", filter)
+	}
+	return &podIPFilter{ip: ip}, nil
+}
+
+// matchesPodIP reports whether any of the pod's IPs equals the filter IP or
+// falls within the filter CIDR. Pods without an assigned IP never match.
+func matchesPodIP(pod *corev1.Pod, filter *podIPFilter) bool {
+	podIPs := []string{pod.Status.PodIP}
+	for _, podIP := range pod.Status.PodIPs {
+		podIPs = append(podIPs, podIP.IP)
+	}
+
+	for _, podIP := range podIPs {
+		ip := net.ParseIP(podIP)
+		if ip == nil {
+			continue
+		}
+		if filter.cidr != nil && filter.cidr.Contains(ip) {
+			return true
+		}
+		if filter.ip != nil && filter.ip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,14 +2,23 @@ package main
 
 import (
 	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func formatAge(t metav1.Time) string {
-	duration := metav1.Now().Sub(t.Time)
+	return formatDuration(metav1.Now().Sub(t.Time))
+}
 
+// formatDuration renders d like formatAge, e.g. "45s", "12m" or "3d".
+func formatDuration(duration time.Duration) string {
 	days := int(duration.Hours() / 24)
 	hours := int(duration.Hours())
 	minutes := int(duration.Minutes())
@@ -26,6 +35,30 @@ func formatAge(t metav1.Time) string {
 	return fmt.Sprintf("%ds", seconds)
 }
 
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// missing returns the placeholder for an enrichment that wasn't attached:
+// <unknown> when resource couldn't be listed, <none> otherwise.
+func (pn PodWithWider) missing(resource string) string {
+	if pn.unavailable[resource] {
+		return "<unknown>"
+	}
+	return "<none>"
+}
+
+// pathRoots are the first elements accepted by getValueByPath, without
+// their aliases such as sa or pvc.
+var pathRoots = []string{
+	"pod", "node", "serviceAccount", "pvcs", "pvs", "pvcMounts",
+	"containers", "initContainers", "ephemeralContainers", "requests", "limits", "readySince",
+	"controller", "replicaSet", "hpa", "vpa", "pdbs", "events", "pullSecrets", "services", "ingresses",
+}
+
 func getValueByPath(pn PodWithWider, path string) (string, error) {
 	// Remove leading dot if present
 	path = strings.TrimPrefix(path, ".")
@@ -38,50 +71,189 @@ func getValueByPath(pn PodWithWider, path string) (string, error) {
 
 	var current interface{}
 
-	switch parts[0] {
+	root, index, indexed, err := parseIndex(parts[0])
+	if err != nil {
+		return "", err
+	}
+
+	switch root {
 	case "pod":
 		current = pn.Pod
 		parts = parts[1:]
 	case "node":
 		if pn.Node == nil {
-			return "<none>", nil
+			return pn.missing("nodes"), nil
 		}
 		current = pn.Node
 		parts = parts[1:]
 	case "serviceAccount", "sa":
 		if pn.ServiceAccount == nil {
-			return "<none>", nil
+			return pn.missing("serviceaccounts"), nil
 		}
 		current = pn.ServiceAccount
 		parts = parts[1:]
 	case "pvcs", "pvc":
 		if len(pn.PVCs) == 0 {
-			return "<none>", nil
+			return pn.missing("persistentvolumeclaims"), nil
 		}
-		// For PVCs array, return comma-separated names or allow indexing
-		if len(parts) == 1 {
-			names := []string{}
-			for _, pvc := range pn.PVCs {
-				names = append(names, pvc.Name)
-			}
-			return strings.Join(names, ","), nil
+		// Like the other lists, return comma-separated names unless indexed
+		if len(parts) == 1 && !indexed {
+			return joinNames(pn.PVCs, (*corev1.PersistentVolumeClaim).GetName), nil
 		}
-		// TODO: Could add array indexing support like pvcs[0].name
 		current = pn.PVCs
 		parts = parts[1:]
+	case "pvs", "pv":
+		if len(pn.PVs) == 0 {
+			return "<none>", nil
+		}
+		// Like PVCs, return comma-separated names unless indexed; claims
+		// without a bound PV show <unbound>
+		if len(parts) == 1 && !indexed {
+			return formatPVs(pn.PVs, pvName), nil
+		}
+		if indexed && index != wildcardIndex && index < len(pn.PVs) && pn.PVs[index] == nil {
+			return "<unbound>", nil
+		}
+		current = pn.PVs
+		parts = parts[1:]
+	case "containers", "initContainers", "ephemeralContainers":
+		summaries := pn.Containers
+		if root == "initContainers" {
+			summaries = pn.InitContainers
+		} else if root == "ephemeralContainers" {
+			summaries = pn.EphemeralContainers
+		}
+		if len(summaries) == 0 {
+			return "<none>", nil
+		}
+		if len(parts) == 1 && !indexed {
+			return joinNames(summaries, func(c ContainerSummary) string { return c.Name }), nil
+		}
+		current = summaries
+		parts = parts[1:]
+	case "controller":
+		if pn.Controller == nil {
+			return "<none>", nil
+		}
+		current = pn.Controller
+		parts = parts[1:]
+	case "replicaSet":
+		if pn.ReplicaSet == nil {
+			return "<none>", nil
+		}
+		current = pn.ReplicaSet
+		parts = parts[1:]
+	case "hpa":
+		if pn.HPA == nil {
+			return "<none>", nil
+		}
+		current = pn.HPA
+		parts = parts[1:]
+	case "vpa":
+		if pn.VPA == nil {
+			return "<none>", nil
+		}
+		current = pn.VPA
+		parts = parts[1:]
+	case "readySince":
+		// The transition time sorts chronologically as a string
+		cond := podReadyCondition(pn.Pod)
+		if cond == nil || cond.LastTransitionTime.IsZero() {
+			return "<none>", nil
+		}
+		return cond.LastTransitionTime.UTC().Format(time.RFC3339), nil
+	case "requests":
+		current = pn.Requests
+		parts = parts[1:]
+	case "limits":
+		current = pn.Limits
+		parts = parts[1:]
+	case "pdbs", "pdb":
+		if len(pn.PDBs) == 0 {
+			return "<none>", nil
+		}
+		if len(parts) == 1 && !indexed {
+			return joinNames(pn.PDBs, (*policyv1.PodDisruptionBudget).GetName), nil
+		}
+		current = pn.PDBs
+		parts = parts[1:]
+	case "events":
+		if len(pn.Events) == 0 {
+			return "<none>", nil
+		}
+		// Events are listed by reason
+		if len(parts) == 1 && !indexed {
+			return joinNames(pn.Events, func(e corev1.Event) string { return e.Reason }), nil
+		}
+		current = pn.Events
+		parts = parts[1:]
+	case "services", "ingresses":
+		names := joinNames(pn.Services, (*corev1.Service).GetName)
+		current = pn.Services
+		if root == "ingresses" {
+			names = joinNames(pn.Ingresses, (*networkingv1.Ingress).GetName)
+			current = pn.Ingresses
+		}
+		if names == "" {
+			return "<none>", nil
+		}
+		if len(parts) == 1 && !indexed {
+			return names, nil
+		}
+		parts = parts[1:]
+	case "pullSecrets":
+		if len(pn.PullSecrets) == 0 {
+			return "<none>", nil
+		}
+		if len(parts) == 1 && !indexed {
+			return joinNames(pn.PullSecrets, func(secret PullSecret) string { return secret.Name }), nil
+		}
+		current = pn.PullSecrets
+		parts = parts[1:]
+	case "pvcMounts":
+		if len(pn.PVCMounts) == 0 {
+			return "<none>", nil
+		}
+		// Mounts are listed by claim name
+		if len(parts) == 1 && !indexed {
+			return joinNames(pn.PVCMounts, func(m PVCMount) string { return m.ClaimName }), nil
+		}
+		current = pn.PVCMounts
+		parts = parts[1:]
 	default:
-		return "", fmt.Errorf("path must start with 'pod' or 'node', got: %s", parts[0])
+		return "", fmt.Errorf("path must start with one of %s, got: %s", strings.Join(pathRoots, ", "), parts[0])
 	}
 
-	if len(parts) == 0 {
-		return fmt.Sprintf("%v", current), nil
+	if indexed {
+		return indexAndResolve(current, index, parts)
+	}
+
+	return resolvePath(current, parts)
+}
+
+// joinNames renders a list root without an index, e.g. .pvcs, as the
+// comma-separated names of its items.
+func joinNames[T any](items []T, name func(T) string) string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, name(item))
 	}
+	return strings.Join(names, ",")
+}
 
+// resolvePath walks parts starting at current, following struct fields by
+// JSON tag or name, map keys and list indexes.
+func resolvePath(current interface{}, parts []string) (string, error) {
 	for i, part := range parts {
 		if part == "" {
 			continue
 		}
 
+		part, index, indexed, err := parseIndex(part)
+		if err != nil {
+			return "", err
+		}
+
 		val := reflect.ValueOf(current)
 
 		// Handle pointers
@@ -99,35 +271,95 @@ func getValueByPath(pn PodWithWider, path string) (string, error) {
 		// Handle map access (e.g., labels[key])
 		if val.Kind() == reflect.Map {
 			key := reflect.ValueOf(part)
+			// Convert to named string key types such as ResourceName
+			if !key.Type().AssignableTo(val.Type().Key()) {
+				if !key.Type().ConvertibleTo(val.Type().Key()) {
+					return "", fmt.Errorf("cannot access key %s on map with %v keys", part, val.Type().Key())
+				}
+				key = key.Convert(val.Type().Key())
+			}
 			mapVal := val.MapIndex(key)
 			if !mapVal.IsValid() {
 				return "<none>", nil
 			}
 			current = mapVal.Interface()
-			continue
+		} else {
+			if val.Kind() != reflect.Struct {
+				return "", fmt.Errorf("cannot access field %s on non-struct type %v", part, val.Kind())
+			}
+
+			// Try to find field by JSON tag first, then by capitalized name
+			field := findFieldByJSONTag(val, part)
+
+			if !field.IsValid() {
+				// Fallback to capitalized field name
+				fieldName := capitalizeFirst(part)
+				field = val.FieldByName(fieldName)
+			}
+
+			if !field.IsValid() {
+				return "", fmt.Errorf("field %s not found", part)
+			}
+
+			current = field.Interface()
 		}
 
-		if val.Kind() != reflect.Struct {
-			return "", fmt.Errorf("cannot access field %s on non-struct type %v", part, val.Kind())
+		// Handle array access (e.g., containers[0] or containers[*])
+		if indexed {
+			return indexAndResolve(current, index, parts[i+1:])
 		}
+	}
 
-		// Try to find field by JSON tag first, then by capitalized name
-		field := findFieldByJSONTag(val, part)
+	return formatValue(current), nil
+}
 
-		if !field.IsValid() {
-			// Fallback to capitalized field name
-			fieldName := capitalizeFirst(part)
-			field = val.FieldByName(fieldName)
+// indexAndResolve indexes into the list current and resolves the remaining
+// parts on the element. The [*] wildcard resolves them on every element and
+// joins the results with commas, like kubectl custom-columns.
+func indexAndResolve(current interface{}, index int, rest []string) (string, error) {
+	if index != wildcardIndex {
+		elem, ok, err := indexValue(current, index)
+		if err != nil {
+			return "", err
 		}
+		if !ok {
+			return "<none>", nil
+		}
+		return resolvePath(elem, rest)
+	}
 
-		if !field.IsValid() {
-			return "", fmt.Errorf("field %s not found", part)
+	val := reflect.ValueOf(current)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return "", fmt.Errorf("cannot index non-array type %v", val.Kind())
+	}
+
+	var values []string
+	for i := 0; i < val.Len(); i++ {
+		v, err := resolvePath(val.Index(i).Interface(), rest)
+		if err != nil {
+			return "", err
+		}
+		if v != "<none>" {
+			values = append(values, v)
 		}
+	}
 
-		current = field.Interface()
+	if len(values) == 0 {
+		return "<none>", nil
 	}
+	return strings.Join(values, ","), nil
+}
 
-	return fmt.Sprintf("%v", current), nil
+// formatValue renders a resolved path value, joining string lists with
+// commas instead of Go's default bracketed format.
+func formatValue(current interface{}) string {
+	switch v := current.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case resource.Quantity:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", current)
 }
 
 func splitPath(path string) []string {
@@ -162,6 +394,42 @@ func splitPath(path string) []string {
 	return parts
 }
 
+// wildcardIndex is the index parseIndex returns for "[*]".
+const wildcardIndex = -1
+
+// parseIndex splits a path part such as "containers[0]" into its name and
+// index. indexed is false when the part has no index.
+func parseIndex(part string) (name string, index int, indexed bool, err error) {
+	open := strings.Index(part, "[")
+	if open == -1 || !strings.HasSuffix(part, "]") {
+		return part, 0, false, nil
+	}
+
+	if part[open+1:len(part)-1] == "*" {
+		return part[:open], wildcardIndex, true, nil
+	}
+
+	index, err = strconv.Atoi(part[open+1 : len(part)-1])
+	if err != nil || index < 0 {
+		return "", 0, false, fmt.Errorf("invalid index in %s", part)
+	}
+
+	return part[:open], index, true, nil
+}
+
+// indexValue returns the element at index of a slice value. ok is false
+// when the index is out of range.
+func indexValue(current interface{}, index int) (interface{}, bool, error) {
+	val := reflect.ValueOf(current)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false, fmt.Errorf("cannot index non-array type %v", val.Kind())
+	}
+	if index >= val.Len() {
+		return nil, false, nil
+	}
+	return val.Index(index).Interface(), true, nil
+}
+
 func findFieldByJSONTag(val reflect.Value, tagName string) reflect.Value {
 	typ := val.Type()
 	for i := 0; i < typ.NumField(); i++ {
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// groupByKeys are the supported --group-by values and the heading printed
+// above each group.
+var groupByKeys = map[string]string{
+	"node":           "NODE",
+	"namespace":      "NAMESPACE",
+	"serviceaccount": "SERVICE ACCOUNT",
+	"owner-kind":     "OWNER KIND",
+}
+
+// groupKey returns the value pn is grouped under for key.
+func groupKey(pn PodWithWider, key string) string {
+	pod := pn.Pod
+	switch key {
+	case "node":
+		if pod.Spec.NodeName == "" {
+			return "<unscheduled>"
+		}
+		return pod.Spec.NodeName
+	case "namespace":
+		return pod.Namespace
+	case "serviceaccount":
+		return valueOrNone(pod.Spec.ServiceAccountName)
+	case "owner-kind":
+		if pn.Controller != nil {
+			return pn.Controller.Kind
+		}
+		if ref := metav1.GetControllerOf(pod); ref != nil {
+			return ref.Kind
+		}
+		return "<none>"
+	}
+	return ""
+}
+
+// printGrouped prints the default table once per group, under a heading
+// with the group's key. The order within each group is preserved.
+func (o *Options) printGrouped(podNodes []PodWithWider) error {
+	grouped := make([]PodWithWider, len(podNodes))
+	copy(grouped, podNodes)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return groupKey(grouped[i], o.GroupBy) < groupKey(grouped[j], o.GroupBy)
+	})
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	// Truncate all groups alike, so their columns stay aligned
+	headers := o.tableHeaders()
+	rows := make([][][]string, len(grouped))
+	var all [][]string
+	for i, pn := range grouped {
+		rows[i] = o.tableRows(pn)
+		all = append(all, rows[i]...)
+	}
+	o.truncateTable(headers, all)
+
+	heading := groupByKeys[o.GroupBy]
+	for i, pn := range grouped {
+		key := groupKey(pn, o.GroupBy)
+		if i == 0 || key != groupKey(grouped[i-1], o.GroupBy) {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s: %s\n", heading, key)
+			if !o.NoHeaders {
+				fmt.Fprintln(w, strings.Join(headers, "\t"))
+			}
+		}
+		for _, row := range rows[i] {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"html/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// htmlTemplate renders a self-contained page with a single table. Cell
+// values are escaped by html/template.
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>kubectl wider</title>
+</head>
+<body>
+<table style="border-collapse: collapse; font-family: monospace; font-size: 13px">
+{{- if .Headers}}
+<thead>
+<tr>{{range .Headers}}<th style="border: 1px solid #ccc; padding: 4px 8px; text-align: left; background-color: #f0f0f0">{{.}}</th>{{end}}</tr>
+</thead>
+{{- end}}
+<tbody>
+{{- range .Rows}}
+<tr style="background-color: {{.Color}}">{{range .Cells}}<td style="border: 1px solid #ccc; padding: 4px 8px">{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// phaseColors are the row background colors for each pod phase.
+var phaseColors = map[corev1.PodPhase]template.CSS{
+	corev1.PodRunning:   "#e6ffed",
+	corev1.PodPending:   "#fff8c5",
+	corev1.PodFailed:    "#ffebe9",
+	corev1.PodSucceeded: "#f6f8fa",
+}
+
+type htmlRow struct {
+	Color template.CSS
+	Cells []string
+}
+
+// printHTML renders the -o wide columns as an HTML table, coloring each
+// row by the pod's phase.
+func (o *Options) printHTML(podNodes []PodWithWider) error {
+	data := struct {
+		Headers []string
+		Rows    []htmlRow
+	}{}
+
+	if !o.NoHeaders {
+		data.Headers = o.tableHeaders()
+	}
+
+	for _, pn := range podNodes {
+		color, ok := phaseColors[pn.Pod.Status.Phase]
+		if !ok {
+			color = "#ffffff"
+		}
+		for _, row := range o.tableRows(pn) {
+			data.Rows = append(data.Rows, htmlRow{Color: color, Cells: row})
+		}
+	}
+
+	return htmlTemplate.Execute(o.Out, data)
+}
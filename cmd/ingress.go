@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// matchingServices returns every Service in the pod's namespace whose
+// selector matches the pod's labels. Services without a selector, whose
+// endpoints are managed by hand, never match.
+func matchingServices(pod *corev1.Pod, services []corev1.Service) []*corev1.Service {
+	var matched []*corev1.Service
+	for i := range services {
+		svc := &services[i]
+		if svc.Namespace != pod.Namespace || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			matched = append(matched, svc)
+		}
+	}
+	return matched
+}
+
+// matchingIngresses returns every Ingress with a backend referencing one of
+// services, whether or not it has a host or an address yet.
+func matchingIngresses(services []*corev1.Service, ingresses []networkingv1.Ingress) []*networkingv1.Ingress {
+	var matched []*networkingv1.Ingress
+	for i := range ingresses {
+		for _, svc := range services {
+			if routesTo(&ingresses[i], svc) {
+				matched = append(matched, &ingresses[i])
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// routesTo reports whether the default backend or a rule of ing references
+// svc.
+func routesTo(ing *networkingv1.Ingress, svc *corev1.Service) bool {
+	if ing.Namespace != svc.Namespace {
+		return false
+	}
+	if references(ing.Spec.DefaultBackend, svc) {
+		return true
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if references(&path.Backend, svc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// references reports whether backend points at svc.
+func references(backend *networkingv1.IngressBackend, svc *corev1.Service) bool {
+	return backend != nil && backend.Service != nil && backend.Service.Name == svc.Name
+}
+
+// ingressHosts returns the hosts through which ing routes to svc. Rules
+// without a host and the default backend are reached through the address
+// of the Ingress itself.
+func ingressHosts(ing *networkingv1.Ingress, svc *corev1.Service) []string {
+	if ing.Namespace != svc.Namespace {
+		return nil
+	}
+
+	var hosts []string
+	if references(ing.Spec.DefaultBackend, svc) {
+		hosts = append(hosts, ingressAddresses(ing)...)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if !references(&path.Backend, svc) {
+				continue
+			}
+			if rule.Host != "" {
+				hosts = append(hosts, rule.Host)
+			} else {
+				hosts = append(hosts, ingressAddresses(ing)...)
+			}
+			break
+		}
+	}
+	return hosts
+}
+
+// loadBalancerAddresses returns the IP, or else the hostname, of every load
+// balancer ingress point.
+func loadBalancerAddresses(points []corev1.LoadBalancerIngress) []string {
+	var addresses []string
+	for _, point := range points {
+		if point.IP != "" {
+			addresses = append(addresses, point.IP)
+		} else if point.Hostname != "" {
+			addresses = append(addresses, point.Hostname)
+		}
+	}
+	return addresses
+}
+
+// ingressAddresses returns the load balancer addresses of an Ingress.
+func ingressAddresses(ing *networkingv1.Ingress) []string {
+	points := make([]corev1.LoadBalancerIngress, 0, len(ing.Status.LoadBalancer.Ingress))
+	for _, point := range ing.Status.LoadBalancer.Ingress {
+		points = append(points, corev1.LoadBalancerIngress{IP: point.IP, Hostname: point.Hostname})
+	}
+	return loadBalancerAddresses(points)
+}
+
+// formatExternal lists the addresses from which the pod is reachable from
+// outside the cluster: the load balancer of its LoadBalancer Services and
+// the hosts of the Ingresses routing to its Services. Load balancers and
+// Ingresses that have no address yet show <pending>.
+func formatExternal(pn PodWithWider) string {
+	var addresses []string
+	seen := map[string]bool{}
+	add := func(values ...string) {
+		for _, value := range values {
+			if !seen[value] {
+				seen[value] = true
+				addresses = append(addresses, value)
+			}
+		}
+	}
+
+	pending := false
+	for _, svc := range pn.Services {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		lb := loadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+		if len(lb) == 0 {
+			pending = true
+		}
+		add(lb...)
+	}
+	for _, ing := range pn.Ingresses {
+		if len(ingressAddresses(ing)) == 0 {
+			pending = true
+		}
+		for _, svc := range pn.Services {
+			add(ingressHosts(ing, svc)...)
+		}
+	}
+
+	if len(addresses) > 0 {
+		return strings.Join(addresses, ",")
+	}
+	if pending {
+		return "<pending>"
+	}
+	return "<none>"
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// isJSONPathAsJSON reports whether -o jsonpath-as-json=... is used.
+func (o *Options) isJSONPathAsJSON() bool {
+	return strings.HasPrefix(o.OutputFormat, "jsonpath-as-json=")
+}
+
+// parseJSONPath parses the -o jsonpath-as-json expression. Like kubectl,
+// the surrounding braces are optional, e.g. .PVCs[*].metadata.name.
+func (o *Options) parseJSONPath() (*jsonpath.JSONPath, error) {
+	expr := strings.TrimPrefix(o.OutputFormat, "jsonpath-as-json=")
+	if expr == "" {
+		return nil, fmt.Errorf("jsonpath-as-json requires an expression, e.g. -o jsonpath-as-json='{.Pod.metadata.name}'")
+	}
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+
+	jp := jsonpath.New("jsonpath-as-json").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath-as-json expression %q: %w", expr, err)
+	}
+	return jp, nil
+}
+
+// printJSONPathAsJSON evaluates the expression over every pod's JSON and
+// prints a JSON array with, for each pod, the array of selected values.
+// Objects and arrays are kept as JSON instead of being printed as text.
+func (o *Options) printJSONPathAsJSON(podNodes []PodWithWider) error {
+	jp, err := o.parseJSONPath()
+	if err != nil {
+		return err
+	}
+	o.stripManagedFields(widerObjects(podNodes))
+
+	selected := make([][]interface{}, 0, len(podNodes))
+	for _, pn := range podNodes {
+		// Evaluate over the JSON form, so paths use the json field names
+		data, err := json.Marshal(pn)
+		if err != nil {
+			return fmt.Errorf("failed to marshal to JSON: %w", err)
+		}
+		var obj interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+
+		results, err := jp.FindResults(obj)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate jsonpath-as-json for %s/%s: %w", pn.Pod.Namespace, pn.Pod.Name, err)
+		}
+		values := []interface{}{}
+		for _, result := range results {
+			for _, value := range result {
+				values = append(values, value.Interface())
+			}
+		}
+		selected = append(selected, values)
+	}
+
+	encoder := json.NewEncoder(o.Out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(selected)
+}
@@ -1,12 +1,47 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	fakemetadata "k8s.io/client-go/metadata/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 )
 
 func TestFormatAge(t *testing.T) {
@@ -201,6 +236,19 @@ func TestGetValueByPath(t *testing.T) {
 		Node:           node,
 		ServiceAccount: sa,
 		PVCs:           []*corev1.PersistentVolumeClaim{pvc},
+		Containers: []ContainerSummary{
+			{Name: "app", Image: "nginx:1.25", State: "Running"},
+			{Name: "sidecar", Image: "envoy:1.29", State: "Running"},
+		},
+		InitContainers: []ContainerSummary{
+			{Name: "init", Image: "busybox:1.36", State: "Terminated"},
+		},
+		PVCMounts: []PVCMount{
+			{ClaimName: "test-pvc", MountPaths: []string{"/data", "/backup"}, ReadOnly: false},
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("250m"),
+		},
 	}
 
 	tests := []struct {
@@ -263,6 +311,84 @@ func TestGetValueByPath(t *testing.T) {
 			expected: "test-pvc",
 			wantErr:  false,
 		},
+		{
+			name:     "indexed pvc name",
+			path:     ".pvcs[0].metadata.name",
+			expected: "test-pvc",
+			wantErr:  false,
+		},
+		{
+			name:     "containers list",
+			path:     ".containers",
+			expected: "app,sidecar",
+			wantErr:  false,
+		},
+		{
+			name:     "indexed container image",
+			path:     ".containers[1].image",
+			expected: "envoy:1.29",
+			wantErr:  false,
+		},
+		{
+			name:     "indexed init container image",
+			path:     ".initContainers[0].image",
+			expected: "busybox:1.36",
+			wantErr:  false,
+		},
+		{
+			name:     "init container index out of range",
+			path:     ".initContainers[1].image",
+			expected: "<none>",
+			wantErr:  false,
+		},
+		{
+			name:     "no ephemeral containers",
+			path:     ".ephemeralContainers[0].name",
+			expected: "<none>",
+			wantErr:  false,
+		},
+		{
+			name:     "indexed pod spec owner reference",
+			path:     ".pod.metadata.ownerReferences[0].kind",
+			expected: "ReplicaSet",
+			wantErr:  false,
+		},
+		{
+			name:     "pvc mounts list",
+			path:     ".pvcMounts",
+			expected: "test-pvc",
+			wantErr:  false,
+		},
+		{
+			name:     "pvc mount paths",
+			path:     ".pvcMounts[0].mountPaths",
+			expected: "/data,/backup",
+			wantErr:  false,
+		},
+		{
+			name:     "pvc mount read only",
+			path:     ".pvcMounts[0].readOnly",
+			expected: "false",
+			wantErr:  false,
+		},
+		{
+			name:     "cpu requests",
+			path:     ".requests.cpu",
+			expected: "250m",
+			wantErr:  false,
+		},
+		{
+			name:     "unset memory requests",
+			path:     ".requests.memory",
+			expected: "<none>",
+			wantErr:  false,
+		},
+		{
+			name:     "invalid index",
+			path:     ".containers[x].image",
+			expected: "",
+			wantErr:  true,
+		},
 		{
 			name:     "pod status phase",
 			path:     ".pod.status.phase",
@@ -324,6 +450,20 @@ func TestGetValueByPath_NilNode(t *testing.T) {
 	}
 }
 
+func TestGetValueByPath_Roots(t *testing.T) {
+	pn := PodWithWider{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod"}}}
+	for _, root := range pathRoots {
+		if _, err := getValueByPath(pn, "."+root+".name"); err != nil {
+			t.Errorf("getValueByPath(.%s.name) unexpected error: %v", root, err)
+		}
+	}
+
+	_, err := getValueByPath(pn, ".bogus.name")
+	if err == nil || !strings.Contains(err.Error(), "pvcMounts") || !strings.Contains(err.Error(), "ingresses") {
+		t.Errorf("getValueByPath(.bogus.name) error = %v, want it to list the supported roots", err)
+	}
+}
+
 func TestGetValueByPath_NilServiceAccount(t *testing.T) {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -366,6 +506,66 @@ func TestGetValueByPath_EmptyPVCs(t *testing.T) {
 	}
 }
 
+func TestPVCMounts(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-pvc"}}},
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+				{Name: "shared", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-pvc"}}},
+				{Name: "locked", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "locked-pvc", ReadOnly: true}}},
+			},
+			InitContainers: []corev1.Container{
+				{Name: "restore", VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/restore"}}},
+			},
+			Containers: []corev1.Container{
+				{Name: "app", VolumeMounts: []corev1.VolumeMount{
+					{Name: "data", MountPath: "/data"},
+					{Name: "shared", MountPath: "/shared", ReadOnly: true},
+					{Name: "config", MountPath: "/etc/app"},
+				}},
+				{Name: "sidecar", VolumeMounts: []corev1.VolumeMount{
+					{Name: "shared", MountPath: "/shared", ReadOnly: true},
+				}},
+			},
+		},
+	}
+
+	expected := []PVCMount{
+		{ClaimName: "data-pvc", MountPaths: []string{"/restore", "/data"}, ReadOnly: false},
+		{ClaimName: "shared-pvc", MountPaths: []string{"/shared"}, ReadOnly: true},
+		{ClaimName: "locked-pvc", ReadOnly: true},
+	}
+
+	result := pvcMounts(pod)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("pvcMounts() = %+v, want %+v", result, expected)
+	}
+}
+
+func TestSummarizeContainers(t *testing.T) {
+	containers := []corev1.Container{
+		{Name: "app", Image: "nginx:1.25"},
+		{Name: "waiting", Image: "busybox:1.36"},
+		{Name: "new", Image: "alpine:3.20"},
+	}
+	statuses := []corev1.ContainerStatus{
+		{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "waiting", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+	}
+
+	expected := []ContainerSummary{
+		{Name: "app", Image: "nginx:1.25", State: "Running"},
+		{Name: "waiting", Image: "busybox:1.36", State: "Waiting"},
+		{Name: "new", Image: "alpine:3.20", State: "Unknown"},
+	}
+
+	result := summarizeContainers(containers, statuses)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("summarizeContainers() = %v, want %v", result, expected)
+	}
+}
+
 func TestFindFieldByJSONTag(t *testing.T) {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -395,19 +595,34 @@ func TestOptionsValidate(t *testing.T) {
 			outputFormat: "",
 			wantErr:      false,
 		},
+		{
+			name:         "wide format",
+			outputFormat: "wide",
+			wantErr:      false,
+		},
 		{
 			name:         "valid custom-columns",
 			outputFormat: "custom-columns=NAME:.pod.metadata.name",
 			wantErr:      false,
 		},
 		{
-			name:         "invalid format",
+			name:         "json format",
 			outputFormat: "json",
-			wantErr:      true,
+			wantErr:      false,
 		},
 		{
-			name:         "invalid format yaml",
+			name:         "yaml format",
 			outputFormat: "yaml",
+			wantErr:      false,
+		},
+		{
+			name:         "invalid format",
+			outputFormat: "xml",
+			wantErr:      true,
+		},
+		{
+			name:         "invalid format prefix",
+			outputFormat: "jsonpath={.pod.metadata.name}",
 			wantErr:      true,
 		},
 	}
@@ -427,3 +642,2926 @@ func TestOptionsValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionsValidateWatchOnly(t *testing.T) {
+	opts := &Options{WatchOnly: true}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for --watch-only without --watch")
+	}
+
+	opts = &Options{Watch: true, WatchOnly: true}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOptionsValidateTLS(t *testing.T) {
+	opts := &Options{InsecureSkipTLSVerify: true, CertificateAuthority: "/tmp/ca.crt"}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for --insecure-skip-tls-verify with --certificate-authority")
+	}
+
+	opts = &Options{CertificateAuthority: "/tmp/ca.crt"}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMatchesPodIP(t *testing.T) {
+	dualStack := &corev1.Pod{
+		Status: corev1.PodStatus{
+			PodIP:  "10.244.1.5",
+			PodIPs: []corev1.PodIP{{IP: "10.244.1.5"}, {IP: "fd00:10:244:1::5"}},
+		},
+	}
+	noIP := &corev1.Pod{}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		filter   string
+		expected bool
+	}{
+		{"exact IPv4", dualStack, "10.244.1.5", true},
+		{"different IPv4", dualStack, "10.244.1.6", false},
+		{"IPv4 CIDR", dualStack, "10.244.0.0/16", true},
+		{"IPv4 CIDR miss", dualStack, "10.245.0.0/16", false},
+		{"exact IPv6", dualStack, "fd00:10:244:1::5", true},
+		{"IPv6 CIDR", dualStack, "fd00:10:244::/48", true},
+		{"IPv6 CIDR miss", dualStack, "fd00:10:245::/48", false},
+		{"no pod IP", noIP, "0.0.0.0/0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parsePodIPFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("parsePodIPFilter(%q) unexpected error: %v", tt.filter, err)
+			}
+			result := matchesPodIP(tt.pod, filter)
+			if result != tt.expected {
+				t.Errorf("matchesPodIP(%q) = %v, want %v", tt.filter, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOptionsValidatePodIP(t *testing.T) {
+	for _, filter := range []string{"10.0.0.1", "10.0.0.0/8", "fd00::1", "fd00::/8"} {
+		opts := &Options{PodIP: filter}
+		if err := opts.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", filter, err)
+		}
+	}
+
+	for _, filter := range []string{"10.0.0", "10.0.0.0/33", "not-an-ip"} {
+		opts := &Options{PodIP: filter}
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected error for %q but got none", filter)
+		}
+	}
+
+	// Validate parses the filter once for keepPod
+	opts := &Options{PodIP: "10.244.0.0/16"}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if !opts.keepPod(&corev1.Pod{Status: corev1.PodStatus{PodIP: "10.244.1.5"}}) {
+		t.Error("keepPod() dropped a pod within --pod-ip")
+	}
+	if opts.keepPod(&corev1.Pod{Status: corev1.PodStatus{PodIP: "10.245.1.5"}}) {
+		t.Error("keepPod() kept a pod outside --pod-ip")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"2d3h", 51 * time.Hour, false},
+		{"1d12h30m", 36*time.Hour + 30*time.Minute, false},
+		{"1d-2h", 0, true},
+		{"1d+2h", 0, true},
+		{"", 0, true},
+		{"-1h", 0, true},
+		{"-2d", 0, true},
+		{"1w", 0, true},
+		{"d", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parseAge(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseAge(%q) expected error but got none", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("parseAge(%q) unexpected error: %v", tt.input, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("parseAge(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchesAge(t *testing.T) {
+	now := time.Now()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		minAge   string
+		maxAge   string
+		expected bool
+	}{
+		{"no bounds", "", "", true},
+		{"older than min", "1h", "", true},
+		{"younger than min", "3h", "", false},
+		{"younger than max", "", "1d", true},
+		{"older than max", "", "1h", false},
+		{"within range", "1h", "3h", true},
+	}
+
+	bound := func(age string) *time.Duration {
+		if age == "" {
+			return nil
+		}
+		d, err := parseAge(age)
+		if err != nil {
+			t.Fatalf("parseAge(%q) unexpected error: %v", age, err)
+		}
+		return &d
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchesAge(pod, bound(tt.minAge), bound(tt.maxAge), now)
+			if result != tt.expected {
+				t.Errorf("matchesAge(%q, %q) = %v, want %v", tt.minAge, tt.maxAge, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOptionsValidateAge(t *testing.T) {
+	opts := &Options{MinAge: "7d", MaxAge: "1h"}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for --min-age greater than --max-age")
+	}
+
+	opts = &Options{MinAge: "-5m"}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for negative --min-age")
+	}
+
+	opts = &Options{MinAge: "1h", MaxAge: "7d"}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Validate parses the ages once for keepPod
+	opts = &Options{MinAge: "1h", MaxAge: "1d12h"}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	created := func(ago time.Duration) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-ago))}}
+	}
+	if !opts.keepPod(created(24 * time.Hour)) {
+		t.Error("keepPod() dropped a pod within the age bounds")
+	}
+	if opts.keepPod(created(30*time.Minute)) || opts.keepPod(created(48*time.Hour)) {
+		t.Error("keepPod() kept a pod outside the age bounds")
+	}
+}
+
+func TestPrintJSONError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected map[string]string
+	}{
+		{
+			name:     "resource error",
+			err:      newResourceError("list", "nodes", errors.New("forbidden")),
+			expected: map[string]string{"error": "failed to list nodes: forbidden", "resource": "nodes"},
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			expected: map[string]string{"error": "boom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printJSONError(&buf, tt.err)
+
+			var result map[string]string
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("output is not valid JSON: %v (%q)", err, buf.String())
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("printJSONError() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyEnvDefaults(t *testing.T) {
+	newFlags := func() (*pflag.FlagSet, *Options) {
+		opts := &Options{}
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.StringVarP(&opts.Namespace, "namespace", "n", "", "")
+		flags.StringVarP(&opts.OutputFormat, "output", "o", "", "")
+		flags.StringVarP(&opts.PodIP, "pod-ip", "", "", "")
+		return flags, opts
+	}
+
+	t.Setenv("KUBECTL_WIDER_NAMESPACE", "staging")
+	t.Setenv("KUBECTL_WIDER_OUTPUT", "wide")
+	t.Setenv("KUBECTL_WIDER_POD_IP", "10.0.0.1")
+
+	flags, opts := newFlags()
+	if err := flags.Parse([]string{"-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEnvDefaults(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.Namespace != "staging" {
+		t.Errorf("namespace = %q, want env default %q", opts.Namespace, "staging")
+	}
+	if opts.OutputFormat != "json" {
+		t.Errorf("output = %q, want explicit flag %q", opts.OutputFormat, "json")
+	}
+	if opts.PodIP != "" {
+		t.Errorf("pod-ip = %q, want it to ignore non-bindable env var", opts.PodIP)
+	}
+}
+
+func TestResolveController(t *testing.T) {
+	isController := true
+	controllerRef := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+	}
+
+	replicaSets := map[string]*appsv1.ReplicaSet{
+		"default/web-6cfd57b89f": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-6cfd57b89f",
+				Namespace:       "default",
+				OwnerReferences: controllerRef("Deployment", "web"),
+			},
+		},
+		"default/standalone": {
+			ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "default"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		expected *Owner
+	}{
+		{"no owner", nil, nil},
+		{"deployment via replicaset", controllerRef("ReplicaSet", "web-6cfd57b89f"), &Owner{Kind: "Deployment", Name: "web"}},
+		{"standalone replicaset", controllerRef("ReplicaSet", "standalone"), &Owner{Kind: "ReplicaSet", Name: "standalone"}},
+		{"unknown replicaset", controllerRef("ReplicaSet", "gone"), &Owner{Kind: "ReplicaSet", Name: "gone"}},
+		{"statefulset", controllerRef("StatefulSet", "db"), &Owner{Kind: "StatefulSet", Name: "db"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", OwnerReferences: tt.owners}}
+			result := resolveController(pod, replicaSets)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("resolveController() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveReplicaSet(t *testing.T) {
+	isController := true
+	controllerRef := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+	}
+
+	replicaSets := map[string]*appsv1.ReplicaSet{
+		"default/web-6cfd57b89f": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web-6cfd57b89f",
+				Namespace:   "default",
+				Annotations: map[string]string{revisionAnnotation: "4"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		expected *Owner
+	}{
+		{"no owner", nil, nil},
+		{"statefulset", controllerRef("StatefulSet", "db"), nil},
+		{"replicaset with revision", controllerRef("ReplicaSet", "web-6cfd57b89f"), &Owner{Kind: "ReplicaSet", Name: "web-6cfd57b89f", Revision: "4"}},
+		{"unknown replicaset", controllerRef("ReplicaSet", "gone"), &Owner{Kind: "ReplicaSet", Name: "gone"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", OwnerReferences: tt.owners}}
+			result := resolveReplicaSet(pod, replicaSets)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("resolveReplicaSet() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindHPA(t *testing.T) {
+	hpas := []autoscalingv2.HorizontalPodAutoscaler{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "other"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "StatefulSet", Name: "db"},
+			},
+		},
+	}
+
+	if hpa := findHPA(hpas, "default", &Owner{Kind: "Deployment", Name: "web"}); hpa == nil || hpa.Name != "web-hpa" {
+		t.Errorf("expected web-hpa for Deployment/web, got %v", hpa)
+	}
+	if hpa := findHPA(hpas, "default", &Owner{Kind: "StatefulSet", Name: "db"}); hpa != nil {
+		t.Errorf("expected no HPA across namespaces, got %v", hpa.Name)
+	}
+	if hpa := findHPA(hpas, "default", nil); hpa != nil {
+		t.Errorf("expected no HPA for pod without controller, got %v", hpa.Name)
+	}
+}
+
+func TestMatchingPDBs(t *testing.T) {
+	pdb := func(name, namespace string, selector *metav1.LabelSelector, allowed int32) policyv1.PodDisruptionBudget {
+		return policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: selector},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+
+	pdbs := []policyv1.PodDisruptionBudget{
+		pdb("web", "default", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, 1),
+		pdb("tier", "default", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"frontend"}},
+		}}, 0),
+		pdb("other-ns", "other", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, 2),
+		pdb("db", "default", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}, 1),
+		pdb("nil-selector", "default", nil, 1),
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web", "tier": "frontend"},
+		},
+	}
+
+	matched := matchingPDBs(pod, pdbs)
+	if len(matched) != 2 || matched[0].Name != "web" || matched[1].Name != "tier" {
+		t.Fatalf("matchingPDBs() = %v, want [web tier]", matched)
+	}
+
+	if result := formatPDBs(matched); result != "web(1),tier(0)" {
+		t.Errorf("formatPDBs() = %q, want %q", result, "web(1),tier(0)")
+	}
+	if result := formatPDBs(nil); result != "<none>" {
+		t.Errorf("formatPDBs(nil) = %q, want <none>", result)
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}
+	}
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "migrate", Resources: requests("1", "64Mi")},
+			},
+			Containers: []corev1.Container{
+				{Name: "app", Resources: requests("250m", "256Mi")},
+				{Name: "sidecar", Resources: requests("100m", "64Mi")},
+			},
+			Overhead: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")},
+		},
+	}
+
+	result := podRequests(pod)
+	cpu := result[corev1.ResourceCPU]
+	memory := result[corev1.ResourceMemory]
+
+	// The init container needs more CPU than the app containers combined
+	if cpu.String() != "1010m" {
+		t.Errorf("cpu requests = %v, want 1010m", cpu.String())
+	}
+	if memory.String() != "320Mi" {
+		t.Errorf("memory requests = %v, want 320Mi", memory.String())
+	}
+	if limits := podLimits(pod); len(limits) != 1 {
+		t.Errorf("expected only the overhead in limits, got %v", limits)
+	}
+}
+
+func TestSortPodNodes(t *testing.T) {
+	podNode := func(name, cpu, memory string) PodWithWider {
+		return PodWithWider{
+			Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}
+	}
+
+	names := func(podNodes []PodWithWider) []string {
+		var result []string
+		for _, pn := range podNodes {
+			result = append(result, pn.Pod.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{"unsorted", &Options{}, []string{"c", "a", "b", "d"}},
+		{"by cpu", &Options{SortByCPU: true}, []string{"b", "a", "c", "d"}},
+		{"by memory", &Options{SortByMemory: true}, []string{"d", "c", "a", "b"}},
+		{"by name path", &Options{SortBy: ".pod.metadata.name"}, []string{"a", "b", "c", "d"}},
+		{"by cpu path", &Options{SortBy: ".requests.cpu"}, []string{"d", "a", "c", "b"}},
+		{"reverse unsorted", &Options{Reverse: true}, []string{"d", "b", "a", "c"}},
+		{"reverse by name path", &Options{SortBy: ".pod.metadata.name", Reverse: true}, []string{"d", "c", "b", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podNodes := []PodWithWider{
+				podNode("c", "500m", "1Gi"),
+				podNode("a", "500m", "512Mi"),
+				podNode("b", "2", "128Mi"),
+				podNode("d", "100m", "2Gi"),
+			}
+			tt.opts.sortPodNodes(podNodes)
+			if result := names(podNodes); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("sortPodNodes() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOptionsValidateMaxPods(t *testing.T) {
+	if err := (&Options{MaxPods: -1}).Validate(); err == nil {
+		t.Error("expected error for negative --max-pods")
+	}
+	if err := (&Options{MaxPods: 10}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOptionsValidateSortFlags(t *testing.T) {
+	opts := &Options{SortBy: ".pod.metadata.name", SortByCPU: true}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for --sort-by with --sort-by-cpu")
+	}
+
+	opts = &Options{SortByCPU: true, SortByMemory: true}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected error for --sort-by-cpu with --sort-by-memory")
+	}
+}
+
+func TestPlannedRequests(t *testing.T) {
+	resources := func(requests []apiRequest) []string {
+		var result []string
+		for _, req := range requests {
+			result = append(result, req.verb+" "+req.resource)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{
+			name:     "default table",
+			opts:     &Options{Namespace: "default"},
+			expected: []string{"get namespaces", "list nodes", "list pods"},
+		},
+		{
+			name:     "custom columns with pvcs",
+			opts:     &Options{Namespace: "default", OutputFormat: "custom-columns=NAME:.pod.metadata.name,PVC:.pvcs"},
+			expected: []string{"get namespaces", "list nodes", "list pods", "list persistentvolumeclaims", "get persistentvolumeclaims"},
+		},
+		{
+			name: "json with hpa, pdb and watch",
+			opts: &Options{AllNamespaces: true, OutputFormat: "json", WithHPA: true, WithPDB: true, Watch: true},
+			expected: []string{
+				"list nodes", "list pods",
+				"list persistentvolumeclaims", "get persistentvolumeclaims",
+				"list serviceaccounts", "get serviceaccounts",
+				"list replicasets", "list horizontalpodautoscalers", "list poddisruptionbudgets",
+				"watch pods", "get nodes",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := resources(tt.opts.plannedRequests())
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("plannedRequests() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetValueByPath_Wildcard(t *testing.T) {
+	container := func(name, cpu string) corev1.Container {
+		c := corev1.Container{Name: name, Image: name + ":latest"}
+		if cpu != "" {
+			c.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+		}
+		return c
+	}
+
+	single := PodWithWider{
+		Pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+			container("app", "250m"),
+		}}},
+	}
+	multi := PodWithWider{
+		Pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+			container("app", "250m"),
+			container("sidecar", "100m"),
+			container("unbounded", ""),
+		}}},
+		Containers: []ContainerSummary{
+			{Name: "app", Image: "app:latest"},
+			{Name: "sidecar", Image: "sidecar:latest"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		pn       PodWithWider
+		path     string
+		expected string
+	}{
+		{"single container cpu", single, ".pod.spec.containers[*].resources.requests.cpu", "250m"},
+		{"multi container cpu", multi, ".pod.spec.containers[*].resources.requests.cpu", "250m,100m"},
+		{"multi container names", multi, ".pod.spec.containers[*].name", "app,sidecar,unbounded"},
+		{"no init containers", multi, ".pod.spec.initContainers[*].name", "<none>"},
+		{"summary images", multi, ".containers[*].image", "app:latest,sidecar:latest"},
+		{"no summaries", single, ".containers[*].image", "<none>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := getValueByPath(tt.pn, tt.path)
+			if err != nil {
+				t.Fatalf("getValueByPath(%q) unexpected error: %v", tt.path, err)
+			}
+			if result != tt.expected {
+				t.Errorf("getValueByPath(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONOmitsOptInFields(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+	)
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", OutputFormat: "json", Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	var pods []map[string]json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &pods); err != nil || len(pods) != 1 {
+		t.Fatalf("invalid JSON output (%v): %s", err, out.String())
+	}
+	var keys []string
+	for key := range pods[0] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := []string{"Containers", "Node", "PVCs", "Pod", "ServiceAccount"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("-o json keys = %v, want %v without opt-in flags", keys, want)
+	}
+}
+
+func TestStripManagedFields(t *testing.T) {
+	newPodNode := func() PodWithWider {
+		managed := []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}}
+		return PodWithWider{
+			Pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", ManagedFields: managed}},
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ManagedFields: managed}},
+			PVCs: []*corev1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: "pvc", ManagedFields: managed}},
+			},
+		}
+	}
+
+	pn := newPodNode()
+	(&Options{}).stripManagedFields([]WiderObject{pn})
+	if pn.Pod.ManagedFields != nil || pn.Node.ManagedFields != nil || pn.PVCs[0].ManagedFields != nil {
+		t.Error("expected managedFields to be stripped")
+	}
+
+	pn = newPodNode()
+	(&Options{ShowManagedFields: true}).stripManagedFields([]WiderObject{pn})
+	if pn.Pod.ManagedFields == nil || pn.Node.ManagedFields == nil || pn.PVCs[0].ManagedFields == nil {
+		t.Error("expected managedFields to be kept with --show-managed-fields")
+	}
+}
+
+func TestGetValueByPath_UnavailableNode(t *testing.T) {
+	pn := PodWithWider{
+		Pod:         &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node1"}},
+		unavailable: map[string]bool{"nodes": true},
+	}
+
+	result, err := getValueByPath(pn, ".node.metadata.name")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if result != "<unknown>" {
+		t.Errorf("expected <unknown> when nodes couldn't be listed, got %v", result)
+	}
+
+	result, err = getValueByPath(pn, ".sa.metadata.name")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if result != "<none>" {
+		t.Errorf("expected <none> for a listed but missing service account, got %v", result)
+	}
+
+	row := (&Options{}).defaultRow(pn)
+	if row[5] != "<unknown>" {
+		t.Errorf("expected <unknown> node IP, got %v", row[5])
+	}
+}
+
+func TestLookupFailed(t *testing.T) {
+	l := &lookups{unavailable: map[string]bool{}}
+
+	if err := (&Options{Strict: true}).lookupFailed(l, "nodes", errors.New("forbidden")); err == nil {
+		t.Error("expected error under --strict")
+	}
+	if l.unavailable["nodes"] {
+		t.Error("expected nodes not to be marked unavailable under --strict")
+	}
+
+	var errOut bytes.Buffer
+	if err := (&Options{ErrOut: &errOut}).lookupFailed(l, "nodes", errors.New("forbidden")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !l.unavailable["nodes"] {
+		t.Error("expected nodes to be marked unavailable")
+	}
+	if !strings.Contains(errOut.String(), "Warning: failed to list nodes: forbidden") {
+		t.Errorf("expected a warning on ErrOut, got %q", errOut.String())
+	}
+}
+
+func TestParseCustomColumnsFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.txt", "# standard view\nNAME:.pod.metadata.name\n\nNODE:.node.metadata.name,OS:.node.metadata.labels.kubernetes\\.io/os\n")
+	headers, paths, err := parseCustomColumnsFile(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(headers, []string{"NAME", "NODE", "OS"}) {
+		t.Errorf("headers = %v", headers)
+	}
+	if !reflect.DeepEqual(paths, []string{".pod.metadata.name", ".node.metadata.name", ".node.metadata.labels.kubernetes\\.io/os"}) {
+		t.Errorf("paths = %v", paths)
+	}
+
+	malformed := write("malformed.txt", "NAME:.pod.metadata.name\n\nNODE\n")
+	if _, _, err := parseCustomColumnsFile(malformed); err == nil || !strings.Contains(err.Error(), "malformed.txt:3:") {
+		t.Errorf("expected error reporting line 3, got %v", err)
+	}
+
+	empty := write("empty.txt", "# nothing here\n")
+	if _, _, err := parseCustomColumnsFile(empty); err == nil {
+		t.Error("expected error for a file without columns")
+	}
+
+	opts := &Options{OutputFormat: "custom-columns-file=" + malformed}
+	if err := opts.Validate(); err == nil {
+		t.Error("expected Validate() to reject a malformed custom-columns file")
+	}
+
+	opts = &Options{OutputFormat: "custom-columns-file=" + valid}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestComputeNodeResources(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("3800m"),
+				corev1.ResourceMemory: resource.MustParse("15Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+
+	pod := func(name, nodeName, cpu, memory string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}}}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	pods := []*corev1.Pod{
+		pod("a", "node1", "500m", "1Gi", corev1.PodRunning),
+		pod("b", "node1", "1", "2Gi", corev1.PodPending),
+		pod("done", "node1", "2", "4Gi", corev1.PodSucceeded),
+		pod("elsewhere", "node2", "2", "4Gi", corev1.PodRunning),
+	}
+
+	result := computeNodeResources(node, pods)
+
+	expected := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    "1500m",
+		corev1.ResourceMemory: "3Gi",
+		corev1.ResourcePods:   "2",
+	}
+	for name, want := range expected {
+		if got := formatQuantity(result.Allocated, name); got != want {
+			t.Errorf("allocated %s = %v, want %v", name, got, want)
+		}
+	}
+	if got := formatQuantity(result.Allocatable, corev1.ResourceCPU); got != "3800m" {
+		t.Errorf("allocatable cpu = %v, want 3800m", got)
+	}
+	if got := formatQuantity(result.Capacity, corev1.ResourceMemory); got != "16Gi" {
+		t.Errorf("capacity memory = %v, want 16Gi", got)
+	}
+	if got := formatQuantity(result.Capacity, "nvidia.com/gpu"); got != "<none>" {
+		t.Errorf("capacity gpu = %v, want <none>", got)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	defer func() { retryBaseDelay = 250 * time.Millisecond }()
+
+	gr := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name         string
+		err          error
+		maxRetries   int
+		wantAttempts int
+	}{
+		{"success", nil, 3, 1},
+		{"forbidden is not retried", apierrors.NewForbidden(gr, "", errors.New("denied")), 3, 1},
+		{"not found is not retried", apierrors.NewNotFound(gr, "web"), 3, 1},
+		{"too many requests", apierrors.NewTooManyRequests("slow down", 0), 3, 4},
+		{"service unavailable", apierrors.NewServiceUnavailable("down"), 2, 3},
+		{"internal error", apierrors.NewInternalError(errors.New("boom")), 1, 2},
+		{"unexpected EOF", io.ErrUnexpectedEOF, 3, 4},
+		{"retries disabled", io.EOF, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			_, err := withRetry(context.Background(), tt.maxRetries, func() (string, error) {
+				attempts++
+				return "", tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("withRetry() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithRetry_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	_, err := withRetry(ctx, 10, func() (string, error) {
+		attempts++
+		cancel()
+		return "", io.EOF
+	})
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("withRetry() error = %v, want %v", err, io.EOF)
+	}
+	if attempts != 1 {
+		t.Errorf("expected cancellation to stop retrying, got %d attempts", attempts)
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	isController := true
+	owned := PodWithWider{Pod: &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc", Controller: &isController}},
+		},
+		Spec: corev1.PodSpec{NodeName: "node1", ServiceAccountName: "web"},
+	}}
+	resolved := owned
+	resolved.Controller = &Owner{Kind: "Deployment", Name: "web"}
+	bare := PodWithWider{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}}}
+
+	tests := []struct {
+		name     string
+		pn       PodWithWider
+		key      string
+		expected string
+	}{
+		{"node", owned, "node", "node1"},
+		{"unscheduled", bare, "node", "<unscheduled>"},
+		{"namespace", bare, "namespace", "kube-system"},
+		{"service account", owned, "serviceaccount", "web"},
+		{"no service account", bare, "serviceaccount", "<none>"},
+		{"owner kind", owned, "owner-kind", "ReplicaSet"},
+		{"resolved owner kind", resolved, "owner-kind", "Deployment"},
+		{"no owner", bare, "owner-kind", "<none>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := groupKey(tt.pn, tt.key); result != tt.expected {
+				t.Errorf("groupKey(%q) = %v, want %v", tt.key, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOptionsValidateGroupBy(t *testing.T) {
+	if err := (&Options{GroupBy: "zone"}).Validate(); err == nil {
+		t.Error("expected error for unsupported --group-by")
+	}
+	if err := (&Options{GroupBy: "node", OutputFormat: "json"}).Validate(); err == nil {
+		t.Error("expected error for --group-by with -o json")
+	}
+	if err := (&Options{GroupBy: "node", OutputFormat: "wide"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBoundPVs(t *testing.T) {
+	claim := func(name string, phase corev1.PersistentVolumeClaimPhase, volume string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volume},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	pvs := map[string]*corev1.PersistentVolume{
+		"pv-data": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+				Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+	}
+
+	bound := boundPVs([]*corev1.PersistentVolumeClaim{
+		claim("data", corev1.ClaimBound, "pv-data"),
+		claim("cache", corev1.ClaimPending, ""),
+	}, pvs)
+	if len(bound) != 2 || bound[0] != pvs["pv-data"] || bound[1] != nil {
+		t.Fatalf("boundPVs() = %v, want [pv-data <nil>]", bound)
+	}
+
+	if got := formatPVs(bound, pvReclaimPolicy); got != "Retain,<unbound>" {
+		t.Errorf("formatPVs(reclaim) = %q, want %q", got, "Retain,<unbound>")
+	}
+	if got := formatPVs(bound, pvCapacity); got != "10Gi,<unbound>" {
+		t.Errorf("formatPVs(capacity) = %q, want %q", got, "10Gi,<unbound>")
+	}
+	if got := formatPVs(nil, pvPhase); got != "<none>" {
+		t.Errorf("formatPVs(nil) = %q, want <none>", got)
+	}
+
+	pn := PodWithWider{PVs: bound}
+	for path, want := range map[string]string{
+		".pvs":                 "pv-data,<unbound>",
+		".pvs[0].status.phase": "Bound",
+		".pvs[1].status.phase": "<unbound>",
+	} {
+		got, err := getValueByPath(pn, path)
+		if err != nil {
+			t.Fatalf("getValueByPath(%q) unexpected error: %v", path, err)
+		}
+		if got != want {
+			t.Errorf("getValueByPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestVolumeAffinity(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "node-a",
+		Labels: map[string]string{"topology.kubernetes.io/zone": "zone-a", "disks": "4"},
+	}}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{NodeSelectorTerms: terms}},
+		}}
+	}
+	expr := func(key string, op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: key, Operator: op, Values: values}}}
+	}
+
+	tests := []struct {
+		name string
+		pv   *corev1.PersistentVolume
+		want string
+	}{
+		{"no affinity", &corev1.PersistentVolume{}, "N/A"},
+		{"zone in", affinity(expr("topology.kubernetes.io/zone", corev1.NodeSelectorOpIn, "zone-a")), "true"},
+		{"zone not in", affinity(expr("topology.kubernetes.io/zone", corev1.NodeSelectorOpIn, "zone-b")), "false"},
+		{"any term", affinity(expr("topology.kubernetes.io/zone", corev1.NodeSelectorOpIn, "zone-b"), expr("disks", corev1.NodeSelectorOpExists)), "true"},
+		{"does not exist", affinity(expr("disks", corev1.NodeSelectorOpDoesNotExist)), "false"},
+		{"gt", affinity(expr("disks", corev1.NodeSelectorOpGt, "2")), "true"},
+		{"lt", affinity(expr("disks", corev1.NodeSelectorOpLt, "2")), "false"},
+		{"field", affinity(corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+		}}), "true"},
+		{"empty term", affinity(corev1.NodeSelectorTerm{}), "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pn := PodWithWider{Pod: &corev1.Pod{}, Node: node, PVs: []*corev1.PersistentVolume{tt.pv, nil}}
+			if got, want := formatVolumeAffinity(pn), tt.want+",<unbound>"; got != want {
+				t.Errorf("formatVolumeAffinity() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if got := formatVolumeAffinity(PodWithWider{Pod: &corev1.Pod{}, PVs: []*corev1.PersistentVolume{{}}}); got != "<none>" {
+		t.Errorf("formatVolumeAffinity(unscheduled) = %q, want <none>", got)
+	}
+}
+
+func TestKeepPod_StatusFilters(t *testing.T) {
+	newPod := func(phase corev1.PodPhase, ready corev1.ConditionStatus, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Status: corev1.PodStatus{
+				Phase:      phase,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+	running := newPod(corev1.PodRunning, corev1.ConditionTrue, map[string]string{"app": "web"})
+	starting := newPod(corev1.PodRunning, corev1.ConditionFalse, map[string]string{"app": "web", "track": "canary"})
+	pending := newPod(corev1.PodPending, corev1.ConditionFalse, nil)
+
+	tests := []struct {
+		name string
+		opts Options
+		pod  *corev1.Pod
+		want bool
+	}{
+		{"no filters", Options{}, pending, true},
+		{"phase match", Options{Phase: "Running"}, running, true},
+		{"phase mismatch", Options{Phase: "Running"}, pending, false},
+		{"ready", Options{Ready: true}, running, true},
+		{"ready excludes not ready", Options{Ready: true}, starting, false},
+		{"not ready", Options{NotReady: true}, starting, true},
+		{"not ready excludes ready", Options{NotReady: true}, running, false},
+		{"exclude selector match", Options{ExcludeSelector: "track=canary"}, starting, false},
+		{"exclude selector no match", Options{ExcludeSelector: "track=canary"}, running, true},
+		{"combined", Options{Phase: "Running", NotReady: true, ExcludeSelector: "track=canary"}, starting, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if got := tt.opts.keepPod(tt.pod); got != tt.want {
+				t.Errorf("keepPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsValidateStatusFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"valid phase", Options{Phase: "Succeeded"}, false},
+		{"invalid phase", Options{Phase: "running"}, true},
+		{"ready and not ready", Options{Ready: true, NotReady: true}, true},
+		{"valid exclude selector", Options{ExcludeSelector: "track!=stable"}, false},
+		{"invalid exclude selector", Options{ExcludeSelector: "a=b=c"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEmptyColumns(t *testing.T) {
+	headers := []string{"NAME", "TYPO"}
+	paths := []string{".pod.metadata.name", ".pod.metadata.nmae"}
+
+	if err := (&Options{StrictColumns: true}).checkEmptyColumns(headers, paths, []int{2, 1}, 2); err != nil {
+		t.Errorf("checkEmptyColumns() unexpected error: %v", err)
+	}
+	if err := (&Options{StrictColumns: true}).checkEmptyColumns(headers, paths, []int{0, 0}, 0); err != nil {
+		t.Errorf("checkEmptyColumns() without rows unexpected error: %v", err)
+	}
+
+	err := (&Options{StrictColumns: true}).checkEmptyColumns(headers, paths, []int{2, 0}, 2)
+	if err == nil || !strings.Contains(err.Error(), ".pod.metadata.nmae") {
+		t.Errorf("checkEmptyColumns() error = %v, want error naming the empty column", err)
+	}
+	var errOut bytes.Buffer
+	if err := (&Options{ErrOut: &errOut}).checkEmptyColumns(headers, paths, []int{2, 0}, 2); err != nil {
+		t.Errorf("checkEmptyColumns() without --strict-columns unexpected error: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "Warning: column TYPO") {
+		t.Errorf("expected a warning about TYPO on ErrOut, got %q", errOut.String())
+	}
+}
+
+func TestPlaceWorkloads(t *testing.T) {
+	pod := func(ns, name, node string, labels map[string]string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+	web := map[string]string{"app": "web"}
+	pods := []corev1.Pod{
+		pod("default", "web-1", "node-b", web),
+		pod("default", "web-2", "node-a", web),
+		pod("default", "web-3", "node-b", web),
+		pod("default", "web-pending", "", web),
+		pod("other", "web-elsewhere", "node-c", web),
+		pod("default", "db-1", "node-c", map[string]string{"app": "db"}),
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: web})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+	workloads := []WorkloadWithWider{{Kind: "Deployment", Workload: deploy, selector: selector}}
+
+	placeWorkloads(workloads, pods)
+
+	want := []NodePlacement{{Node: "node-a", Pods: 1}, {Node: "node-b", Pods: 2}}
+	if !reflect.DeepEqual(workloads[0].Placement, want) {
+		t.Errorf("Placement = %v, want %v", workloads[0].Placement, want)
+	}
+	if got := formatPlacement(workloads[0].Placement); got != "node-a(1),node-b(2)" {
+		t.Errorf("formatPlacement() = %q, want %q", got, "node-a(1),node-b(2)")
+	}
+	if got := placedPods(workloads[0].Placement); got != 3 {
+		t.Errorf("placedPods() = %d, want 3", got)
+	}
+	if got := formatPlacement(nil); got != "<none>" {
+		t.Errorf("formatPlacement(nil) = %q, want <none>", got)
+	}
+
+	// Workloads share the json and yaml path of pods
+	deploy.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}
+	var out bytes.Buffer
+	if err := (&Options{Out: &out, OutputFormat: "yaml", YAMLStream: true}).printWorkloads(workloads); err != nil {
+		t.Fatalf("printWorkloads() unexpected error: %v", err)
+	}
+	if deploy.ManagedFields != nil || !strings.Contains(out.String(), "name: web") {
+		t.Errorf("printWorkloads(-o yaml) = %q with managedFields %v, want them stripped", out.String(), deploy.ManagedFields)
+	}
+}
+
+func TestOptionsValidateResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"unset", Options{}, false},
+		{"pods", Options{Resource: "pods", Watch: true}, false},
+		{"deployments", Options{Resource: "deployments", OutputFormat: "json"}, false},
+		{"unsupported", Options{Resource: "jobs"}, true},
+		{"workload custom columns", Options{Resource: "daemonsets", OutputFormat: "custom-columns=NAME:.pod.metadata.name"}, true},
+		{"workload watch", Options{Resource: "statefulsets", Watch: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTMLOutput(t *testing.T) {
+	o := &Options{OutputFormat: "html", ShowLabels: true}
+
+	headers := o.defaultHeaders()
+	if !reflect.DeepEqual(headers[len(headers)-9:], []string{"POD-IP", "HOST-IP", "READY-SINCE", "SCHED-STATUS", "PLACEMENT-OK", "SCHEDULER", "PREEMPTION", "TERMINATING-FOR", "LABELS"}) {
+		t.Errorf("defaultHeaders() = %v, want the wide columns followed by LABELS", headers)
+	}
+
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]interface{}{
+		"Headers": headers,
+		"Rows":    []htmlRow{{Color: phaseColors[corev1.PodFailed], Cells: []string{"<script>alert(1)</script>"}}},
+	})
+	if err != nil {
+		t.Fatalf("htmlTemplate.Execute() unexpected error: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "<script>") || !strings.Contains(out, "&lt;script&gt;") {
+		t.Errorf("expected cell values to be escaped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "background-color: #ffebe9") {
+		t.Errorf("expected Failed rows to be colored, got:\n%s", out)
+	}
+}
+
+func TestTreeOutput(t *testing.T) {
+	pod := func(name, node string, phase corev1.PodPhase, claims ...string) PodWithWider {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: node, ServiceAccountName: "default"},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		var pvcs []*corev1.PersistentVolumeClaim
+		for _, claim := range claims {
+			p.Spec.Volumes = append(p.Spec.Volumes, corev1.Volume{
+				Name:         claim,
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+			})
+			pvcs = append(pvcs, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: claim},
+				Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+			})
+		}
+		return PodWithWider{Pod: p, PVCs: pvcs}
+	}
+
+	var buf bytes.Buffer
+	o := &Options{OutputFormat: "tree", Out: &buf}
+	err := o.print([]PodWithWider{
+		pod("pending", "", corev1.PodPending),
+		pod("web", "node-b", corev1.PodRunning, "data"),
+		pod("db", "node-a", corev1.PodRunning),
+		pod("cache", "node-b", corev1.PodRunning),
+	})
+	if err != nil {
+		t.Fatalf("print() unexpected error: %v", err)
+	}
+
+	want := `node-a
+└── db (Running)
+    └── sa: default
+node-b
+├── web (Running)
+│   ├── sa: default
+│   └── pvc: data (Bound)
+└── cache (Running)
+    └── sa: default
+<unscheduled>
+└── pending (Pending)
+    └── sa: default
+`
+	if got := buf.String(); got != want {
+		t.Errorf("tree output =\n%s\nwant:\n%s", got, want)
+	}
+
+	if !(&Options{OutputFormat: "tree"}).requirements().pvcs {
+		t.Error("expected -o tree to look up the PVCs")
+	}
+
+	if err := (&Options{OutputFormat: "tree", Watch: true}).Validate(); err == nil {
+		t.Error("expected an error for -o tree with --watch")
+	}
+}
+
+func TestJSONPathAsJSON(t *testing.T) {
+	claim := func(name string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	podNodes := []PodWithWider{
+		{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0"}}, PVCs: []*corev1.PersistentVolumeClaim{claim("data"), claim("logs")}},
+		{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"jsonpath-as-json={.PVCs[*].metadata.name}", `[["data","logs"],[]]`},
+		{"jsonpath-as-json=.Pod.metadata.name", `[["web-0"],["web-1"]]`},
+		{"jsonpath-as-json={.Pod.metadata}", `[[{"name":"web-0"}],[{"name":"web-1"}]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			o := &Options{OutputFormat: tt.format, Out: &out}
+			if err := o.Validate(); err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if err := o.print(podNodes); err != nil {
+				t.Fatalf("print() unexpected error: %v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", out.String(), err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("output = %s, want %s", out.String(), tt.want)
+			}
+		})
+	}
+
+	for _, format := range []string{"jsonpath-as-json=", "jsonpath-as-json={.Pod.metadata.name"} {
+		if err := (&Options{OutputFormat: format}).Validate(); err == nil {
+			t.Errorf("Validate(%q) expected an error", format)
+		}
+	}
+}
+
+func TestFormatSchedulingStatus(t *testing.T) {
+	unschedulable := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/3 nodes are available:\n3 Insufficient cpu.",
+	}
+	gated := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonSchedulingGated,
+		Message: "Scheduling is blocked due to non-empty scheduling gates",
+	}
+	gates := []corev1.PodSchedulingGate{{Name: "example.com/quota"}, {Name: "example.com/team"}}
+
+	tests := []struct {
+		name       string
+		nodeName   string
+		gates      []corev1.PodSchedulingGate
+		conditions []corev1.PodCondition
+		want       string
+	}{
+		{"scheduled", "node-a", nil, nil, "<scheduled>"},
+		{"no condition yet", "", nil, nil, "<none>"},
+		{"unschedulable", "", nil, []corev1.PodCondition{unschedulable}, "Unschedulable: 0/3 nodes are available: 3 Insufficient cpu."},
+		{"gated", "", gates, []corev1.PodCondition{gated}, "gated: example.com/quota,example.com/team"},
+		{"gated and unschedulable", "", gates[:1], []corev1.PodCondition{unschedulable}, "gated: example.com/quota; Unschedulable: 0/3 nodes are available: 3 Insufficient cpu."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Spec:   corev1.PodSpec{NodeName: tt.nodeName, SchedulingGates: tt.gates},
+				Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: tt.conditions},
+			}
+			if got := formatSchedulingStatus(pod); got != tt.want {
+				t.Errorf("formatSchedulingStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatPlacementCheck(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "node-a",
+		Labels: map[string]string{"disktype": "ssd", "topology.kubernetes.io/zone": "zone-a"},
+	}}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	zone := func(op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "topology.kubernetes.io/zone", Operator: op, Values: values},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		nodeName string
+		spec     corev1.PodSpec
+		want     string
+	}{
+		{"unscheduled", "", corev1.PodSpec{NodeSelector: map[string]string{"disktype": "hdd"}}, "N/A"},
+		{"no constraints", "node-a", corev1.PodSpec{}, "OK"},
+		{"node selector", "node-a", corev1.PodSpec{NodeSelector: map[string]string{"disktype": "ssd"}}, "OK"},
+		{"node selector drift", "node-a", corev1.PodSpec{NodeSelector: map[string]string{"disktype": "hdd"}}, "MISMATCH: disktype=hdd"},
+		{"affinity", "node-a", corev1.PodSpec{Affinity: affinity(zone(corev1.NodeSelectorOpIn, "zone-a", "zone-b"))}, "OK"},
+		{"affinity drift", "node-a", corev1.PodSpec{Affinity: affinity(zone(corev1.NodeSelectorOpIn, "zone-b"), zone(corev1.NodeSelectorOpDoesNotExist))},
+			"MISMATCH: topology.kubernetes.io/zone in (zone-b) || !topology.kubernetes.io/zone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.NodeName = tt.nodeName
+			pn := PodWithWider{Pod: &corev1.Pod{Spec: tt.spec}, Node: node}
+			if got := formatPlacementCheck(pn); got != tt.want {
+				t.Errorf("formatPlacementCheck() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateTable(t *testing.T) {
+	headers := []string{"NAME", "IMAGES"}
+	newRows := func() [][]string {
+		return [][]string{
+			{"web-7d4b9c8f5-abcde", "registry.example.com/team/web:1.2.3,registry.example.com/team/sidecar:4.5.6"},
+			{"db-0", "postgres:16"},
+		}
+	}
+
+	// --max-column-width applies outside a terminal too
+	rows := newRows()
+	(&Options{MaxColumnWidth: 10, Out: &bytes.Buffer{}}).truncateTable(headers, rows)
+	want := [][]string{{"web-7d4b9…", "registry.…"}, {"db-0", "postgres:…"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("truncateTable(--max-column-width 10) = %v, want %v", rows, want)
+	}
+
+	// Not a terminal and no fixed width: values are kept
+	rows = newRows()
+	(&Options{Out: &bytes.Buffer{}}).truncateTable(headers, rows)
+	if !reflect.DeepEqual(rows, newRows()) {
+		t.Errorf("truncateTable() outside a terminal = %v, want full values", rows)
+	}
+
+	widths := fitColumns(headers, newRows(), 50)
+	if !reflect.DeepEqual(widths, []int{19, 28}) {
+		t.Errorf("fitColumns(50) = %v, want [19 28]", widths)
+	}
+	widths = fitColumns(headers, newRows(), 10)
+	if !reflect.DeepEqual(widths, []int{12, 12}) {
+		t.Errorf("fitColumns(10) = %v, want the minimum width [12 12]", widths)
+	}
+
+	if got := truncateCell("äöü-long", 4); got != "äöü…" {
+		t.Errorf("truncateCell() = %q, want %q", got, "äöü…")
+	}
+
+	if err := (&Options{MaxColumnWidth: 20, NoTruncate: true}).Validate(); err == nil {
+		t.Error("expected an error for --max-column-width with --no-truncate")
+	}
+}
+
+func TestSchedulerColumns(t *testing.T) {
+	never := corev1.PreemptNever
+	lower := corev1.PreemptLowerPriority
+	pods := []*corev1.Pod{
+		{Spec: corev1.PodSpec{SchedulerName: corev1.DefaultSchedulerName, PreemptionPolicy: &lower}},
+		{Spec: corev1.PodSpec{SchedulerName: "volcano", PreemptionPolicy: &never}},
+		{Spec: corev1.PodSpec{}},
+	}
+
+	tests := []struct {
+		hideDefaults bool
+		want         [][2]string
+	}{
+		{false, [][2]string{{"default-scheduler", "PreemptLowerPriority"}, {"volcano", "Never"}, {"<none>", "<none>"}}},
+		{true, [][2]string{{"-", "-"}, {"volcano", "Never"}, {"<none>", "<none>"}}},
+	}
+	for _, tt := range tests {
+		o := &Options{HideDefaults: tt.hideDefaults}
+		for i, pod := range pods {
+			got := [2]string{o.formatSchedulerName(pod), o.formatPreemptionPolicy(pod)}
+			if got != tt.want[i] {
+				t.Errorf("hideDefaults=%v: pod %d columns = %v, want %v", tt.hideDefaults, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestTerminating(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	grace := int64(30)
+	pod := func(deletionTimestamp time.Time) *corev1.Pod {
+		ts := metav1.NewTime(deletionTimestamp)
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &ts, DeletionGracePeriodSeconds: &grace}}
+	}
+
+	if got := formatTerminatingFor(&corev1.Pod{}, now); got != "<none>" {
+		t.Errorf("formatTerminatingFor(running) = %q, want <none>", got)
+	}
+	// Deleted 10s ago with 20s of the grace period left
+	if got := formatTerminatingFor(pod(now.Add(20*time.Second)), now); got != "10s (grace 30s)" {
+		t.Errorf("formatTerminatingFor(within grace) = %q, want %q", got, "10s (grace 30s)")
+	}
+	// Deleted 12m30s ago
+	if got := formatTerminatingFor(pod(now.Add(-12*time.Minute)), now); got != "12m (grace 30s) overdue" {
+		t.Errorf("formatTerminatingFor(stuck) = %q, want %q", got, "12m (grace 30s) overdue")
+	}
+
+	o := &Options{Terminating: true}
+	if o.keepPod(&corev1.Pod{}) {
+		t.Error("expected --terminating to leave out pods that aren't being deleted")
+	}
+	if !o.keepPod(pod(now)) {
+		t.Error("expected --terminating to keep pods being deleted")
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if got := formatLabels(nil); got != "<none>" {
+		t.Errorf("formatLabels(nil) = %q, want <none>", got)
+	}
+	if got := formatLabels(map[string]string{"tier": "web", "app": "shop"}); got != "app=shop,tier=web" {
+		t.Errorf("formatLabels() = %q, want %q", got, "app=shop,tier=web")
+	}
+}
+
+func TestReadySince(t *testing.T) {
+	transition := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+	ready := PodWithWider{Pod: &corev1.Pod{Status: corev1.PodStatus{
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: transition},
+		},
+	}}}
+	pending := PodWithWider{Pod: &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}}
+
+	if got := formatReadySince(ready.Pod); got != "3h" {
+		t.Errorf("formatReadySince() = %q, want 3h", got)
+	}
+	if got := formatReadySince(pending.Pod); got != "<none>" {
+		t.Errorf("formatReadySince() without condition = %q, want <none>", got)
+	}
+
+	got, err := getValueByPath(ready, ".readySince")
+	if err != nil {
+		t.Fatalf("getValueByPath(.readySince) unexpected error: %v", err)
+	}
+	if want := transition.UTC().Format(time.RFC3339); got != want {
+		t.Errorf("getValueByPath(.readySince) = %q, want %q", got, want)
+	}
+	if got, _ := getValueByPath(pending, ".readySince"); got != "<none>" {
+		t.Errorf("getValueByPath(.readySince) without condition = %q, want <none>", got)
+	}
+}
+
+func TestCompactPod(t *testing.T) {
+	pn := PodWithWider{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec: corev1.PodSpec{
+				NodeName:           "node-a",
+				ServiceAccountName: "web",
+				Volumes: []corev1.Volume{
+					{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+					{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-0"}}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, QOSClass: corev1.PodQOSBurstable},
+		},
+		Node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeHostName, Address: "node-a"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.5"},
+		}}},
+	}
+
+	data, err := json.Marshal(compactPod(pn))
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"name":"web-0","namespace":"shop","node":"node-a","nodeIP":"10.0.0.5","serviceAccount":"web","pvcs":["data-web-0"],"phase":"Running","qosClass":"Burstable"}`
+	if string(data) != want {
+		t.Errorf("compactPod() = %s, want %s", data, want)
+	}
+
+	pending := PodWithWider{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}}}
+	if got := compactPod(pending); got.PVCs == nil || got.NodeIP != "" {
+		t.Errorf("compactPod() = %+v, want empty pvcs list and no node IP", got)
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	namespaces := []string{"default", "kube-system", "kube-public", "monitoring", "shop"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"defualt", []string{`"default"`}},
+		{"kube-sytem", []string{`"kube-system"`}},
+		{"kube", []string{`"kube-public"`, `"kube-system"`}},
+		{"unrelated", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similarNames(tt.name, namespaces); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("similarNames(%q) = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtendedResourceColumns(t *testing.T) {
+	gpu := corev1.ResourceName("nvidia.com/gpu")
+	gpuNode := &corev1.Node{Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{gpu: resource.MustParse("4")}}}
+	cpuNode := &corev1.Node{Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")}}}
+
+	training := PodWithWider{
+		Pod:      &corev1.Pod{Spec: corev1.PodSpec{NodeName: "gpu-1"}},
+		Node:     gpuNode,
+		Requests: corev1.ResourceList{gpu: resource.MustParse("2")},
+	}
+	web := PodWithWider{Pod: &corev1.Pod{Spec: corev1.PodSpec{NodeName: "cpu-1"}}, Node: cpuNode}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		podNodes []PodWithWider
+		expected []corev1.ResourceName
+	}{
+		{"default output", Options{}, []PodWithWider{training}, nil},
+		{"wide with gpu pod", Options{OutputFormat: "wide"}, []PodWithWider{web, training}, []corev1.ResourceName{gpu}},
+		{"wide without gpus", Options{OutputFormat: "wide"}, []PodWithWider{web}, nil},
+		{"explicit", Options{ExtendedResource: "example.com/fpga"}, []PodWithWider{web}, []corev1.ResourceName{"example.com/fpga"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.extendedResourceColumns(tt.podNodes); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("extendedResourceColumns() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	o := &Options{extendedColumns: []corev1.ResourceName{gpu}}
+	headers := o.defaultHeaders()
+	if !reflect.DeepEqual(headers[len(headers)-2:], []string{"NVIDIA.COM/GPU", "NODE-NVIDIA.COM/GPU"}) {
+		t.Errorf("defaultHeaders() = %v, want the extended resource columns last", headers)
+	}
+	row := o.defaultRow(training)
+	if !reflect.DeepEqual(row[len(row)-2:], []string{"2", "4"}) {
+		t.Errorf("defaultRow() = %v, want request 2 and allocatable 4", row)
+	}
+	row = o.defaultRow(web)
+	if !reflect.DeepEqual(row[len(row)-2:], []string{"0", "<none>"}) {
+		t.Errorf("defaultRow() = %v, want request 0 and allocatable <none>", row)
+	}
+}
+
+func TestIsExtendedResourceName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"nvidia.com/gpu":            true,
+		"example.com/fpga":          true,
+		"cpu":                       false,
+		"kubernetes.io/foo":         false,
+		"hugepages.kubernetes.io/x": false,
+		"/gpu":                      false,
+	} {
+		if got := isExtendedResourceName(name); got != want {
+			t.Errorf("isExtendedResourceName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestGroupPodEvents(t *testing.T) {
+	now := time.Now()
+	event := func(kind, name, reason string, age time.Duration) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "default", Name: name},
+			Reason:         reason,
+			Message:        "message\nfor " + reason,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+	events := []corev1.Event{
+		event("Pod", "web", "Scheduled", 10*time.Minute),
+		event("Pod", "web", "BackOff", time.Minute),
+		event("Pod", "web", "Pulled", 5*time.Minute),
+		event("Node", "web", "NodeReady", 0),
+		event("Pod", "db", "Started", time.Hour),
+	}
+
+	grouped := groupPodEvents(events, 2)
+	var reasons []string
+	for _, e := range grouped["default/web"] {
+		reasons = append(reasons, e.Reason)
+	}
+	if !reflect.DeepEqual(reasons, []string{"BackOff", "Pulled"}) {
+		t.Errorf("groupPodEvents() reasons = %v, want [BackOff Pulled]", reasons)
+	}
+	if len(grouped["default/db"]) != 1 || len(grouped) != 2 {
+		t.Errorf("groupPodEvents() = %v, want events for web and db only", grouped)
+	}
+	if got := len(groupPodEvents(events, 0)["default/web"]); got != 3 {
+		t.Errorf("groupPodEvents() without limit kept %d events, want 3", got)
+	}
+
+	if got := formatLatestEvent(grouped["default/web"]); got != "BackOff: message for BackOff" {
+		t.Errorf("formatLatestEvent() = %q, want %q", got, "BackOff: message for BackOff")
+	}
+	if got := formatLatestEvent(nil); got != "<none>" {
+		t.Errorf("formatLatestEvent(nil) = %q, want <none>", got)
+	}
+}
+
+func TestAllNamespacesOrderAndColumn(t *testing.T) {
+	pod := func(ns, name string) PodWithWider {
+		return PodWithWider{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}}}
+	}
+	names := func(podNodes []PodWithWider) []string {
+		var result []string
+		for _, pn := range podNodes {
+			result = append(result, pn.Pod.Namespace+"/"+pn.Pod.Name)
+		}
+		return result
+	}
+
+	podNodes := []PodWithWider{pod("shop", "web"), pod("default", "b"), pod("shop", "api"), pod("default", "a")}
+	(&Options{}).sortPodNodes(podNodes)
+	if got := names(podNodes); !reflect.DeepEqual(got, []string{"shop/web", "default/b", "shop/api", "default/a"}) {
+		t.Errorf("sortPodNodes() without -A reordered pods: %v", got)
+	}
+
+	o := &Options{AllNamespaces: true}
+	o.sortPodNodes(podNodes)
+	if got := names(podNodes); !reflect.DeepEqual(got, []string{"default/a", "default/b", "shop/api", "shop/web"}) {
+		t.Errorf("sortPodNodes() under -A = %v, want namespace then name order", got)
+	}
+
+	if headers := o.defaultHeaders(); headers[0] != "NAMESPACE" {
+		t.Errorf("defaultHeaders() under -A = %v, want a leading NAMESPACE column", headers)
+	}
+	if row := o.defaultRow(podNodes[0]); row[0] != "default" || row[1] != "a" {
+		t.Errorf("defaultRow() under -A = %v, want namespace before name", row)
+	}
+	if headers := (&Options{}).defaultHeaders(); headers[0] != "NAME" {
+		t.Errorf("defaultHeaders() = %v, want no NAMESPACE column without -A", headers)
+	}
+
+	if got, _ := getValueByPath(podNodes[0], ".pod.metadata.namespace"); got != "default" {
+		t.Errorf("getValueByPath(.pod.metadata.namespace) = %q, want default", got)
+	}
+}
+
+func TestPrintOutput(t *testing.T) {
+	podNodes := []PodWithWider{{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop", CreationTimestamp: metav1.Now()},
+			Spec:       corev1.PodSpec{NodeName: "node-a", Containers: []corev1.Container{{Name: "web"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}}
+
+	var out bytes.Buffer
+	o := &Options{Out: &out, NoHeaders: true}
+	if err := o.print(podNodes); err != nil {
+		t.Fatalf("print() unexpected error: %v", err)
+	}
+	if fields := strings.Fields(out.String()); !reflect.DeepEqual(fields, []string{"web-0", "0/1", "Running", "0", "0s", "node-a"}) {
+		t.Errorf("print() = %q, want a single row without headers", out.String())
+	}
+
+	out.Reset()
+	o = &Options{Out: &out, OutputFormat: "json-compact"}
+	if err := o.print(podNodes); err != nil {
+		t.Fatalf("print() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), `{"name":"web-0","namespace":"shop"`) || strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("print() json-compact = %q, want a single compact line", out.String())
+	}
+}
+
+func TestRunWithFakeClientset(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.5"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop", CreationTimestamp: metav1.Now()},
+			Spec:       corev1.PodSpec{NodeName: "node-a", Containers: []corev1.Container{{Name: "web"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}},
+	)
+
+	var out, errOut bytes.Buffer
+	o := &Options{Namespace: "shop", Clientset: clientset, Out: &out, ErrOut: &errOut, NoHeaders: true}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if fields := strings.Fields(out.String()); !reflect.DeepEqual(fields, []string{"web-0", "0/1", "Running", "0", "0s", "10.0.0.5", "node-a"}) {
+		t.Errorf("Run() output = %q, want only web-0 with its node IP", out.String())
+	}
+
+	o = &Options{Namespace: "shpo", Clientset: clientset, Out: &out, ErrOut: &errOut}
+	err := o.Run()
+	if err == nil || !strings.Contains(err.Error(), `did you mean "shop"`) {
+		t.Errorf("Run() error = %v, want a suggestion for the misspelt namespace", err)
+	}
+}
+
+func TestSelectorFromPod(t *testing.T) {
+	pod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: labels, CreationTimestamp: metav1.Now()},
+			Spec:       corev1.PodSpec{NodeName: "node-a"},
+		}
+	}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		pod("web-1", map[string]string{"app": "web", "track": "stable", "pod-template-hash": "abc"}),
+		pod("web-2", map[string]string{"app": "web", "track": "stable", "pod-template-hash": "def"}),
+		pod("web-canary", map[string]string{"app": "web", "track": "canary", "pod-template-hash": "ghi"}),
+		pod("db", map[string]string{"app": "db"}),
+	)
+
+	run := func(o *Options) ([]string, error) {
+		var out, errOut bytes.Buffer
+		o.Namespace, o.Clientset, o.Out, o.ErrOut, o.NoHeaders = "shop", clientset, &out, &errOut, true
+		err := o.Run()
+		var names []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				names = append(names, fields[0])
+			}
+		}
+		return names, err
+	}
+
+	names, err := run(&Options{SelectorFromPod: "web-1"})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"web-1", "web-2"}) {
+		t.Errorf("--selector-from-pod web-1 = %v, want [web-1 web-2]", names)
+	}
+
+	names, err = run(&Options{SelectorFromPod: "web-1", SelectorLabels: []string{"app"}, LabelSelector: "track!=stable"})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"web-canary"}) {
+		t.Errorf("--selector-labels app -l track!=stable = %v, want [web-canary]", names)
+	}
+
+	if _, err := run(&Options{SelectorFromPod: "web-3"}); err == nil || !strings.Contains(err.Error(), `reference pod "web-3" not found`) {
+		t.Errorf("Run() error = %v, want a not found error", err)
+	}
+	if _, err := run(&Options{SelectorFromPod: "web-1", SelectorLabels: []string{"tier"}}); err == nil || !strings.Contains(err.Error(), `no label "tier"`) {
+		t.Errorf("Run() error = %v, want a missing label error", err)
+	}
+}
+
+func TestAnnotateWarnings(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "shop"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec:       corev1.PodSpec{NodeName: "gone", ServiceAccountName: "web"},
+		},
+	)
+
+	for _, annotate := range []bool{false, true} {
+		var out bytes.Buffer
+		o := &Options{Namespace: "shop", OutputFormat: "json", AnnotateWarnings: annotate, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+		if err := o.Run(); err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+
+		var pods []map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &pods); err != nil {
+			t.Fatalf("invalid JSON output: %v", err)
+		}
+		warnings, ok := pods[0]["_warnings"].([]interface{})
+		if !annotate {
+			if ok {
+				t.Errorf("expected no _warnings without --annotate-warnings, got %v", warnings)
+			}
+			continue
+		}
+		if len(warnings) != 2 || warnings[0] != "node gone not found" || !strings.HasPrefix(warnings[1].(string), "serviceaccount web fetch failed: ") {
+			t.Errorf("_warnings = %v, want the missing node and service account", warnings)
+		}
+	}
+
+	if err := (&Options{AnnotateWarnings: true, OutputFormat: "wide"}).Validate(); err == nil {
+		t.Error("expected an error for --annotate-warnings with -o wide")
+	}
+}
+
+func TestEnrichPodsConcurrency(t *testing.T) {
+	var objects []runtime.Object
+	var pods []*corev1.Pod
+	for i := range 20 {
+		name := fmt.Sprintf("web-%d", i)
+		objects = append(objects, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"}})
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       corev1.PodSpec{ServiceAccountName: name},
+		})
+	}
+
+	// Only an unrelated account is listed, so every pod falls back to a Get
+	l := &lookups{
+		serviceAccounts: map[string]*corev1.ServiceAccount{"shop/default": {}},
+		unavailable:     map[string]bool{},
+	}
+	o := &Options{Concurrency: 4, Clientset: fake.NewClientset(objects...)}
+	podNodes := o.enrichPods(context.Background(), pods, l)
+
+	if len(podNodes) != len(pods) {
+		t.Fatalf("enrichPods() returned %d pods, want %d", len(podNodes), len(pods))
+	}
+	for i, pn := range podNodes {
+		if pn.Pod != pods[i] {
+			t.Errorf("enrichPods()[%d] = %s, want %s", i, pn.Pod.Name, pods[i].Name)
+		}
+		if pn.ServiceAccount == nil || pn.ServiceAccount.Name != pods[i].Name {
+			t.Errorf("enrichPods()[%d] service account = %v, want %s", i, pn.ServiceAccount, pods[i].Name)
+		}
+	}
+
+	if got := o.enrichPods(context.Background(), nil, l); len(got) != 0 {
+		t.Errorf("enrichPods(nil) = %v, want none", got)
+	}
+}
+
+func TestRunVersion(t *testing.T) {
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.31.2"}
+
+	var out, errOut bytes.Buffer
+	o := &Options{Clientset: clientset, Namespace: "default", Out: &out, ErrOut: &errOut}
+	if err := o.RunVersion(); err != nil {
+		t.Fatalf("RunVersion() unexpected error: %v", err)
+	}
+	want := "Plugin version: dev\nGit commit: unknown\nBuild date: unknown\nServer version: v1.31.2\n"
+	if out.String() != want || errOut.Len() != 0 {
+		t.Errorf("RunVersion() = %q (stderr %q), want %q", out.String(), errOut.String(), want)
+	}
+
+	root := NewRootCommand()
+	out.Reset()
+	root.SetOut(&out)
+	root.SetArgs([]string{"--version"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("--version unexpected error: %v", err)
+	}
+	if out.String() != buildInfo() {
+		t.Errorf("--version = %q, want %q", out.String(), buildInfo())
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write from a watch goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchResync(t *testing.T) {
+	clientset := fake.NewClientset()
+	var out syncBuffer
+	o := &Options{Namespace: "shop", Watch: true, WatchOnly: true, NoHeaders: true, OutputFormat: "wide", Resync: 10 * time.Millisecond, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l, err := o.fetchLookups(ctx, "shop")
+	if err != nil {
+		t.Fatalf("fetchLookups() unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- o.watch(ctx, "shop", "", nil, l) }()
+
+	// The node is created after startup and only known once resynced
+	_, err = clientset.CoreV1().Nodes().Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.5"}}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = clientset.CoreV1().Pods("shop").Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "web-0") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watch() unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "10.0.0.5") {
+		t.Errorf("watch() output = %q, want the IP of the node created after startup", out.String())
+	}
+}
+
+func TestPullSecrets(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}, {Name: "regcred"}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec: corev1.PodSpec{
+				ServiceAccountName: "web",
+				ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "regcred"}, {Name: "regcerd"}},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"}},
+	)
+	secret := func(name string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		}
+	}
+	scheme := fakemetadata.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme, secret("regcred"), secret("mirror"))
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", OutputFormat: "json", WithPullSecrets: true, Clientset: clientset, Metadata: metadataClient, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	var podNodes []PodWithWider
+	if err := json.Unmarshal(out.Bytes(), &podNodes); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	want := []PullSecret{
+		{Name: "regcred", Source: "pod", Found: true},
+		{Name: "regcerd", Source: "pod", Found: false},
+		{Name: "mirror", Source: "serviceAccount", Found: true},
+	}
+	if !reflect.DeepEqual(podNodes[0].PullSecrets, want) {
+		t.Errorf("PullSecrets = %+v, want %+v", podNodes[0].PullSecrets, want)
+	}
+	if got := formatPullSecrets(podNodes[0]); got != "missing: regcerd" {
+		t.Errorf("formatPullSecrets() = %q, want %q", got, "missing: regcerd")
+	}
+	if got := formatPullSecrets(PodWithWider{Pod: &corev1.Pod{}, PullSecrets: want[:1]}); got != "OK" {
+		t.Errorf("formatPullSecrets() = %q, want OK", got)
+	}
+	if got := formatPullSecrets(podNodes[1]); got != "<none>" {
+		t.Errorf("formatPullSecrets() = %q, want <none>", got)
+	}
+
+	// The secrets of a service account that can't be read are unknown
+	ctx := context.Background()
+	l, err := o.fetchLookups(ctx, "shop")
+	if err != nil {
+		t.Fatalf("fetchLookups() unexpected error: %v", err)
+	}
+	ghost := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "ghost",
+			ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "regcred"}},
+		},
+	}
+	pn := o.enrichPod(ctx, ghost, l)
+	if got := formatPullSecrets(pn); got != "<unknown>" {
+		t.Errorf("formatPullSecrets() with an unreadable service account = %q, want <unknown>", got)
+	}
+	if l.unavailable["serviceaccounts"] {
+		t.Error("a failed service account fetch marked service accounts unavailable for every pod")
+	}
+}
+
+func TestCompleteBuildsMetadataForInjectedClientset(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	config := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: shop
+current-context: test
+`
+	if err := os.WriteFile(kubeconfig, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &Options{WithPullSecrets: true, WithVPA: true, Clientset: fake.NewClientset(), ConfigFlags: &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig}}
+	if err := o.Complete(); err != nil {
+		t.Fatalf("Complete() unexpected error: %v", err)
+	}
+	if o.Metadata == nil || o.Dynamic == nil {
+		t.Errorf("Complete() with an injected clientset left Metadata = %v, Dynamic = %v", o.Metadata, o.Dynamic)
+	}
+}
+
+func TestSchemaV1(t *testing.T) {
+	pn := PodWithWider{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec: corev1.PodSpec{
+				NodeName:           "node-a",
+				ServiceAccountName: "web",
+				Volumes: []corev1.Volume{{
+					Name:         "data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-0"}},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, QOSClass: corev1.PodQOSBurstable},
+		},
+		Node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.5"}}}},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+
+	var out bytes.Buffer
+	o := &Options{OutputFormat: "yaml", Schema: "v1", Out: &out}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if err := o.print([]PodWithWider{pn}); err != nil {
+		t.Fatalf("print() unexpected error: %v", err)
+	}
+	want := `- name: web-0
+  namespace: shop
+  node: node-a
+  nodeIP: 10.0.0.5
+  phase: Running
+  pvcs:
+  - data-web-0
+  qosClass: Burstable
+  requests:
+    cpu: 250m
+    memory: 128Mi
+  schemaVersion: v1
+  serviceAccount: web
+
+`
+	if out.String() != want {
+		t.Errorf("print() =\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	for _, o := range []*Options{
+		{OutputFormat: "json", Schema: "v2"},
+		{OutputFormat: "wide", Schema: "v1"},
+		{OutputFormat: "json", Schema: "v1", Resource: "deployments"},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(-o %s --schema %s --resource %s) expected an error", o.OutputFormat, o.Schema, o.Resource)
+		}
+	}
+}
+
+func TestCheckServerVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		wantWarning bool
+	}{
+		{"v1.31.2", false},
+		{"v1.23.0", false},
+		{"v1.22.17-eks-1234", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			clientset := fake.NewClientset()
+			clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: tt.version}
+
+			var errOut bytes.Buffer
+			(&Options{Clientset: clientset, ErrOut: &errOut}).checkServerVersion()
+			if got := strings.Contains(errOut.String(), "older than the minimum"); got != tt.wantWarning {
+				t.Errorf("checkServerVersion() warning = %q, want warning %v", errOut.String(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestPrintYAMLStream(t *testing.T) {
+	newPodNodes := func() []PodWithWider {
+		return []PodWithWider{
+			{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"}}},
+			{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"}}},
+		}
+	}
+
+	var out bytes.Buffer
+	if err := (&Options{Out: &out, OutputFormat: "yaml", YAMLStream: true}).printStructured(widerObjects(newPodNodes())); err != nil {
+		t.Fatalf("printStructured() unexpected error: %v", err)
+	}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(&out))
+	var names []string
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading YAML stream: %v", err)
+		}
+		var pn PodWithWider
+		if err := yaml.Unmarshal(doc, &pn); err != nil {
+			t.Fatalf("yaml.Unmarshal() unexpected error: %v", err)
+		}
+		names = append(names, pn.Pod.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"web-0", "web-1"}) {
+		t.Errorf("YAML stream documents = %v, want [web-0 web-1]", names)
+	}
+
+	out.Reset()
+	if err := (&Options{Out: &out, OutputFormat: "yaml"}).printStructured(widerObjects(newPodNodes())); err != nil {
+		t.Fatalf("printStructured() unexpected error: %v", err)
+	}
+	var list []PodWithWider
+	if err := yaml.Unmarshal(out.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Errorf("printYAML() without --yaml-stream = %d pods (err %v), want a single list of 2", len(list), err)
+	}
+
+	if err := (&Options{YAMLStream: true, OutputFormat: "json"}).Validate(); err == nil {
+		t.Error("expected --yaml-stream to require -o yaml")
+	}
+}
+
+func TestOvercommit(t *testing.T) {
+	node := func(name, cpu, memory string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}
+	}
+	pod := func(ns, name, nodeName, cpu string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+			Spec: corev1.PodSpec{NodeName: nodeName, Containers: []corev1.Container{{
+				Name:      "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	clientset := fake.NewClientset(
+		node("busy", "2", "4Gi"),
+		node("idle", "4", "8Gi"),
+		pod("shop", "web", "busy", "1500m"),
+		pod("batch", "job", "busy", "1100m"),
+		pod("shop", "api", "idle", "500m"),
+	)
+	o := &Options{Namespace: "shop", Clientset: clientset, WithOvercommit: true}
+	l := &lookups{nodes: map[string]*corev1.Node{}}
+	for _, name := range []string{"busy", "idle"} {
+		n, err := clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.nodes[name] = n
+	}
+
+	nodeResources, err := o.nodeResources(context.Background(), l, &corev1.PodList{})
+	if err != nil {
+		t.Fatalf("nodeResources() unexpected error: %v", err)
+	}
+
+	busy := PodWithWider{Pod: pod("shop", "web", "busy", "1500m"), NodeResources: nodeResources["busy"]}
+	if got := formatOvercommit(busy); got != "cpu(130%)" {
+		t.Errorf("formatOvercommit() on busy node = %q, want cpu(130%%) counting pods of every namespace", got)
+	}
+	idle := PodWithWider{Pod: pod("shop", "api", "idle", "500m"), NodeResources: nodeResources["idle"]}
+	if got := formatOvercommit(idle); got != "<none>" {
+		t.Errorf("formatOvercommit() on idle node = %q, want <none>", got)
+	}
+}
+
+func TestByContainer(t *testing.T) {
+	pn := PodWithWider{Pod: &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			NodeName:           "node-a",
+			ServiceAccountName: "web",
+			InitContainers:     []corev1.Container{{Name: "migrate", Image: "migrate:1"}},
+			Containers: []corev1.Container{{
+				Name:  "web",
+				Image: "web:2",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "web", RestartCount: 3}}},
+	}}
+
+	var out bytes.Buffer
+	o := &Options{Out: &out, ByContainer: true, AllNamespaces: true}
+	if err := o.printDefault([]PodWithWider{pn}); err != nil {
+		t.Fatalf("printDefault() unexpected error: %v", err)
+	}
+
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		rows = append(rows, strings.Fields(line))
+	}
+	expected := [][]string{
+		{"NAMESPACE", "POD", "CONTAINER", "IMAGE", "CPU-REQ", "CPU-LIM", "MEM-REQ", "MEM-LIM", "RESTARTS", "NODE", "SERVICEACCOUNT"},
+		{"shop", "web-0", "init:migrate", "migrate:1", "<none>", "<none>", "<none>", "<none>", "0", "node-a", "web"},
+		{"shop", "web-0", "web", "web:2", "250m", "<none>", "128Mi", "256Mi", "3", "node-a", "web"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("printDefault() --by-container =\n%v\nwant\n%v", rows, expected)
+	}
+
+	if err := (&Options{ByContainer: true, OutputFormat: "json"}).Validate(); err == nil {
+		t.Error("expected --by-container to be rejected with -o json")
+	}
+}
+
+func TestOutputFormatError(t *testing.T) {
+	err := (&Options{OutputFormat: "xml"}).Validate()
+
+	var fe *outputFormatError
+	if !errors.As(err, &fe) {
+		t.Fatalf("Validate() error = %v, want an outputFormatError", err)
+	}
+	for _, format := range outputFormats {
+		if !strings.Contains(err.Error(), format) {
+			t.Errorf("error %q doesn't list supported format %s", err.Error(), format)
+		}
+		if !strings.HasSuffix(format, "=") {
+			if err := (&Options{OutputFormat: format}).Validate(); err != nil {
+				t.Errorf("Validate() rejected supported format %s: %v", format, err)
+			}
+		}
+	}
+}
+
+func TestAnnotationColumns(t *testing.T) {
+	pn := PodWithWider{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-0",
+		Annotations: map[string]string{
+			"prometheus.io/scrape": "true",
+			"example.com/note":     "multi\nline  value",
+		},
+	}}}
+
+	o := &Options{ShowAnnotations: true, Annotations: []string{"prometheus.io/scrape", "prometheus.io/port"}}
+	headers := o.defaultHeaders()
+	if !reflect.DeepEqual(headers[len(headers)-3:], []string{"ANNOTATIONS", "SCRAPE", "PORT"}) {
+		t.Errorf("defaultHeaders() = %v, want ANNOTATIONS, SCRAPE and PORT last", headers)
+	}
+
+	row := o.defaultRow(pn)
+	expected := []string{"example.com/note=multi line value,prometheus.io/scrape=true", "true", "<none>"}
+	if !reflect.DeepEqual(row[len(row)-3:], expected) {
+		t.Errorf("defaultRow() = %v, want %v last", row, expected)
+	}
+
+	if got := formatAnnotations(nil); got != "<none>" {
+		t.Errorf("formatAnnotations(nil) = %q, want <none>", got)
+	}
+}
+
+func TestVPA(t *testing.T) {
+	isController := true
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-7d4b9",
+			Namespace:       "shop",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &isController}},
+		}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-7d4b9-abcde",
+				Namespace:       "shop",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9", Controller: &isController}},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("128Mi"),
+				}},
+			}}},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "shop"}},
+	)
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "shop"},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{map[string]interface{}{
+					"containerName": "app",
+					"target":        map[string]interface{}{"cpu": "250m", "memory": "300Mi"},
+				}},
+			},
+		},
+	}}
+	listKinds := map[schema.GroupVersionResource]string{vpaResource: "VerticalPodAutoscalerList"}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, vpa)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", OutputFormat: "json", WithVPA: true, Clientset: clientset, Dynamic: dynamicClient, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	var podNodes []PodWithWider
+	if err := json.Unmarshal(out.Bytes(), &podNodes); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if podNodes[0].VPA != nil {
+		t.Errorf("VPA of %s = %+v, want nil", podNodes[0].Pod.Name, podNodes[0].VPA)
+	}
+	if got := formatVPA(podNodes[1].VPA); got != "cpu:100m->250m,memory:128Mi->300Mi" {
+		t.Errorf("formatVPA() = %q, want %q", got, "cpu:100m->250m,memory:128Mi->300Mi")
+	}
+	if got, err := getValueByPath(podNodes[1], ".vpa.containers[0].recommended.cpu"); err != nil || got != "250m" {
+		t.Errorf("getValueByPath(.vpa.containers[0].recommended.cpu) = %q, %v, want 250m", got, err)
+	}
+	if got := formatVPA(&VPARecommendation{Name: "web", Containers: []VPAContainer{{Name: "app"}}}); got != "<pending>" {
+		t.Errorf("formatVPA() = %q, want <pending>", got)
+	}
+
+	// Without the CRD the run only warns
+	missing := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	missing.PrependReactor("list", "verticalpodautoscalers", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(vpaResource.GroupResource(), "")
+	})
+	var errOut bytes.Buffer
+	o = &Options{Namespace: "shop", OutputFormat: "wide", WithVPA: true, Clientset: clientset, Dynamic: missing, Out: io.Discard, ErrOut: &errOut}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() without the CRD unexpected error: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "isn't installed") {
+		t.Errorf("expected a warning about the missing CRD, got %q", errOut.String())
+	}
+	// A --watch resync lists the VPAs again without repeating the warning
+	if _, err := o.listVPAs(context.Background(), "shop"); err != nil {
+		t.Fatalf("listVPAs() without the CRD unexpected error: %v", err)
+	}
+	if n := strings.Count(errOut.String(), "isn't installed"); n != 1 {
+		t.Errorf("warning about the missing CRD printed %d times, want once", n)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	pod := func(name, node, image string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: "app", Image: image}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}
+	clientsetA := fake.NewClientset(ns,
+		pod("cart-0", "node-a", "shop/cart:2", corev1.PodRunning),
+		pod("db-0", "node-a", "postgres:16", corev1.PodRunning),
+		pod("web-0", "node-a", "shop/web:1.4", corev1.PodRunning),
+	)
+	clientsetB := fake.NewClientset(ns,
+		pod("db-0", "node-a", "postgres:16", corev1.PodRunning),
+		pod("web-0", "node-7", "shop/web:1.5", corev1.PodRunning),
+		pod("worker-0", "", "shop/worker:1", corev1.PodPending),
+	)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", Diff: true, Context: "old", ContextB: "new", Clientset: clientsetA, ClientsetB: clientsetB, Out: &out, ErrOut: io.Discard}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	want := `NAMESPACE   NAME       DIFFERENCE
+shop        cart-0     only in old
+shop        web-0      node: node-a -> node-7; image: shop/web:1.4 -> shop/web:1.5
+shop        worker-0   only in new
+`
+	if out.String() != want {
+		t.Errorf("diff output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	o.OutputFormat = "json"
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var diffs []PodDiff
+	if err := json.Unmarshal(out.Bytes(), &diffs); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	wantChanges := []FieldChange{{Field: "node", A: "node-a", B: "node-7"}, {Field: "image", A: "shop/web:1.4", B: "shop/web:1.5"}}
+	if len(diffs) != 3 || !reflect.DeepEqual(diffs[1].Changes, wantChanges) {
+		t.Errorf("diffs = %+v, want web-0 changes %+v", diffs, wantChanges)
+	}
+
+	for _, o := range []*Options{{ContextB: "new"}, {Diff: true}, {Diff: true, ContextB: "new", OutputFormat: "yaml"}} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate() with diff=%v context-b=%q -o %q: expected an error", o.Diff, o.ContextB, o.OutputFormat)
+		}
+	}
+
+	if err := executeWithEnvOutput(t, "wide", "--diff", "--context-b", "new"); err == nil || !strings.Contains(err.Error(), "failed to load kubeconfig") {
+		t.Errorf("--diff with KUBECTL_WIDER_OUTPUT=wide: expected to pass validation, got %v", err)
+	}
+
+	// The namespace is checked against context-b too
+	o = &Options{Namespace: "shop", Diff: true, Context: "old", ContextB: "new", Clientset: clientsetA, ClientsetB: fake.NewClientset(), Out: io.Discard, ErrOut: io.Discard}
+	if err := o.Run(); err == nil || !strings.Contains(err.Error(), "context new") {
+		t.Errorf("Run() with the namespace missing from context-b: expected an error, got %v", err)
+	}
+
+	out.Reset()
+	o = &Options{Namespace: "shop", Diff: true, Context: "old", ContextB: "new", ExplainRequests: true, Out: &out}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() with --explain-requests unexpected error: %v", err)
+	}
+	for _, note := range []string{"context old, checks that shop exists", "context new, checks that shop exists"} {
+		if !strings.Contains(out.String(), note) {
+			t.Errorf("plan doesn't contain %q:\n%s", note, out.String())
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLoggingRoundTripper(t *testing.T) {
+	klogFlags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(klogFlags)
+	var logs bytes.Buffer
+	klog.LogToStderr(false)
+	klog.SetOutput(&logs)
+	defer func() {
+		klog.SetOutput(io.Discard)
+		klog.LogToStderr(true)
+		_ = klogFlags.Set("v", "0")
+	}()
+
+	rt := &loggingRoundTripper{next: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}
+	req := httptest.NewRequest(http.MethodGet, "https://cluster/api/v1/namespaces/shop/pods?limit=500", nil)
+
+	for _, tt := range []struct {
+		verbosity string
+		want      bool
+	}{{"0", false}, {"4", true}} {
+		logs.Reset()
+		if err := klogFlags.Set("v", tt.verbosity); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip() unexpected error: %v", err)
+		}
+		klog.Flush()
+		if got := strings.Contains(logs.String(), "GET /api/v1/namespaces/shop/pods?limit=500 200 in"); got != tt.want {
+			t.Errorf("-v %s: logged request = %v, want %v (logs %q)", tt.verbosity, got, tt.want, logs.String())
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	pod := func(name, namespace string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": "web"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		pod("web-0", "shop", corev1.PodRunning),
+		pod("web-1", "shop", corev1.PodPending),
+		pod("web-0", "staging", corev1.PodRunning),
+		pod("web-1", "staging", corev1.PodRunning),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "shop"}},
+	)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", LabelSelector: "app=web", Count: true, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if out.String() != "2\n" {
+		t.Errorf("count = %q, want %q", out.String(), "2\n")
+	}
+
+	out.Reset()
+	o = &Options{AllNamespaces: true, Phase: "Running", Count: true, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	want := `NAMESPACE   PODS
+shop        1
+staging     2
+TOTAL       3
+`
+	if out.String() != want {
+		t.Errorf("count under -A:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// Nothing but the pods is listed
+	for _, action := range clientset.Actions() {
+		if action.GetResource().Resource != "pods" && action.GetResource().Resource != "namespaces" {
+			t.Errorf("unexpected %s of %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+
+	o = &Options{Count: true, OutputFormat: "wide"}
+	if err := o.Validate(); err == nil {
+		t.Error("Validate() with --count -o wide: expected an error")
+	}
+}
+
+func TestIngressExternal(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	backend := func(service string) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: service, Port: networkingv1.ServiceBackendPort{Number: 80}}}
+	}
+	rule := func(host, service string) networkingv1.IngressRule {
+		return networkingv1.IngressRule{Host: host, IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+			Paths: []networkingv1.HTTPIngressPath{{Path: "/", PathType: &pathType, Backend: backend(service)}},
+		}}}
+	}
+	pod := func(name string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: podLabels}}
+	}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		pod("api-0", map[string]string{"app": "api"}),
+		pod("db-0", map[string]string{"app": "db"}),
+		pod("web-0", map[string]string{"app": "web", "tier": "frontend"}),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "web"}},
+			Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"tier": "frontend"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "api"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "db"}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "shop"},
+			Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{rule("shop.example.com", "frontend"), rule("", "frontend"), rule("api.example.com", "other")}},
+			Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+				Ingress: []networkingv1.IngressLoadBalancerIngress{{Hostname: "lb.example.net"}},
+			}},
+		},
+		// Neither a host nor an address yet
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: "shop"},
+			Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{rule("", "db")}},
+		},
+	)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", OutputFormat: "json", WithIngress: true, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	var podNodes []PodWithWider
+	if err := json.Unmarshal(out.Bytes(), &podNodes); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	want := map[string]string{
+		"api-0": "<pending>",
+		"db-0":  "<pending>",
+		"web-0": "203.0.113.10,shop.example.com,lb.example.net",
+	}
+	for _, pn := range podNodes {
+		if got := formatExternal(pn); got != want[pn.Pod.Name] {
+			t.Errorf("formatExternal(%s) = %q, want %q", pn.Pod.Name, got, want[pn.Pod.Name])
+		}
+	}
+	if got, err := getValueByPath(podNodes[2], ".services"); err != nil || got != "frontend,web" {
+		t.Errorf("getValueByPath(.services) = %q, %v, want frontend,web", got, err)
+	}
+	if got, err := getValueByPath(podNodes[2], ".ingresses"); err != nil || got != "shop" {
+		t.Errorf("getValueByPath(.ingresses) = %q, %v, want shop", got, err)
+	}
+	if got, err := getValueByPath(podNodes[1], ".ingresses"); err != nil || got != "internal" {
+		t.Errorf("getValueByPath(.ingresses) = %q, %v, want internal", got, err)
+	}
+	if got, err := getValueByPath(podNodes[0], ".ingresses[0].name"); err != nil || got != "<none>" {
+		t.Errorf("getValueByPath(.ingresses[0].name) = %q, %v, want <none>", got, err)
+	}
+}
+
+func TestSpreadBy(t *testing.T) {
+	node := func(name, zone string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if zone != "" {
+			n.Labels["topology.kubernetes.io/zone"] = zone
+		}
+		return n
+	}
+	pod := func(name, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		node("node-a", "zone-a"), node("node-b", "zone-a"), node("node-c", "zone-c"), node("edge", ""),
+		pod("web-0", "node-a"), pod("web-1", "node-b"), pod("web-2", "node-a"), pod("web-3", "node-a"),
+		pod("web-4", "edge"), pod("web-5", ""),
+	)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", LabelSelector: "app=web", SpreadBy: "topology.kubernetes.io/zone", Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	bar := func(n int) string { return strings.Repeat("#", n) }
+	row := func(value, histogram, pods string) string {
+		return fmt.Sprintf("%-30s%-43s%s\n", value, histogram, pods)
+	}
+	want := row("TOPOLOGY.KUBERNETES.IO/ZONE", "HISTOGRAM", "PODS") +
+		row("zone-a", bar(40), "4") +
+		row("zone-c", "", "0") +
+		row("<unlabeled>", bar(10), "1") +
+		row("<unscheduled>", bar(10), "1")
+	if out.String() != want {
+		t.Errorf("spread output:\n%q\nwant:\n%q", out.String(), want)
+	}
+
+	o = &Options{SpreadBy: "kubernetes.io/hostname", OutputFormat: "json"}
+	if err := o.Validate(); err == nil {
+		t.Error("Validate() with --spread-by -o json: expected an error")
+	}
+}
+
+func TestOnlyProblems(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	pod := func(name, nodeName string, phase corev1.PodPhase, restarts int32) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+			},
+		}
+	}
+	terminating := pod("leaving", "node-a", corev1.PodRunning, 0)
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		node("node-a", corev1.ConditionTrue), node("node-b", corev1.ConditionFalse),
+		pod("healthy", "node-a", corev1.PodRunning, 5),
+		pod("done", "node-a", corev1.PodSucceeded, 0),
+		pod("crashing", "node-a", corev1.PodRunning, 6),
+		pod("pending", "", corev1.PodPending, 0),
+		pod("failed", "node-a", corev1.PodFailed, 0),
+		pod("stranded", "node-b", corev1.PodRunning, 0),
+		pod("orphaned", "node-gone", corev1.PodRunning, 0),
+		terminating,
+	)
+
+	var out bytes.Buffer
+	o := &Options{Namespace: "shop", OutputFormat: "custom-columns=NAME:.pod.metadata.name", NoHeaders: true, OnlyProblems: true, RestartThreshold: 5, Clientset: clientset, Out: &out, ErrOut: io.Discard}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if err := o.Run(); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	got := strings.Fields(out.String())
+	want := []string{"crashing", "failed", "leaving", "orphaned", "pending", "stranded"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("--only-problems kept %v, want %v", got, want)
+	}
+
+	for _, o := range []*Options{{OnlyProblems: true, Count: true}, {RestartThreshold: -1}} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate() with only-problems=%v count=%v restart-threshold=%d: expected an error", o.OnlyProblems, o.Count, o.RestartThreshold)
+		}
+	}
+}
+
+func TestOnlyProblemsWatchNewNode(t *testing.T) {
+	joined := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "joined"},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+	}
+	pod := func(nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	// The nodes were listed before "joined" was added
+	l := &lookups{nodes: map[string]*corev1.Node{}}
+
+	clientset := fake.NewClientset(joined)
+	o := &Options{Watch: true, OnlyProblems: true, Clientset: clientset, ErrOut: io.Discard}
+	if pn := o.enrichPod(context.Background(), pod("joined"), l); pn.Node == nil || o.hasProblem(pn) {
+		t.Errorf("pod on a node added after the list: node = %v, problem = %v, want the node fetched and no problem", pn.Node, o.hasProblem(pn))
+	}
+	if pn := o.enrichPod(context.Background(), pod("gone"), l); !o.hasProblem(pn) {
+		t.Error("pod on a deleted node: expected a problem")
+	}
+
+	clientset.PrependReactor("get", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	pn := o.enrichPod(context.Background(), pod("joined"), l)
+	if o.hasProblem(pn) || formatOvercommit(pn) != "<unknown>" {
+		t.Errorf("pod on a node that couldn't be fetched: problem = %v, want the node treated as unknown", o.hasProblem(pn))
+	}
+}
+
+// executeWithEnvOutput runs the root command with args and
+// KUBECTL_WIDER_OUTPUT set, without a reachable cluster, and returns its
+// error.
+func executeWithEnvOutput(t *testing.T, output string, args ...string) error {
+	t.Helper()
+	t.Setenv("KUBECTL_WIDER_OUTPUT", output)
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+
+	cmd := NewRootCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	return cmd.Execute()
+}
+
+func TestCountWithEnvOutput(t *testing.T) {
+	// Validation passes, so the run fails later on the missing kubeconfig
+	err := executeWithEnvOutput(t, "wide", "--count")
+	if err == nil || !strings.Contains(err.Error(), "failed to load kubeconfig") {
+		t.Errorf("--count with KUBECTL_WIDER_OUTPUT=wide: got %v, want the env output ignored", err)
+	}
+
+	err = executeWithEnvOutput(t, "wide", "--count", "-o", "wide")
+	if err == nil || !strings.Contains(err.Error(), "--count can't be used with -o") {
+		t.Errorf("--count -o wide: got %v, want it rejected", err)
+	}
+}
+
+func TestSpreadByWithEnvOutput(t *testing.T) {
+	err := executeWithEnvOutput(t, "json", "--spread-by", "topology.kubernetes.io/zone")
+	if err == nil || !strings.Contains(err.Error(), "failed to load kubeconfig") {
+		t.Errorf("--spread-by with KUBECTL_WIDER_OUTPUT=json: got %v, want the env output ignored", err)
+	}
+
+	err = executeWithEnvOutput(t, "json", "--spread-by", "topology.kubernetes.io/zone", "-o", "json")
+	if err == nil || !strings.Contains(err.Error(), "--spread-by can't be used with -o") {
+		t.Errorf("--spread-by -o json: got %v, want it rejected", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+)
+
+// Verbosity levels of the plugin's own logs. Level 0 stays silent.
+const (
+	logRequests  klog.Level = 4 // every API request with its duration, and timings
+	logLookups   klog.Level = 5 // hits and misses on the fetched lookups
+	logSelectors klog.Level = 6 // the resolved label and field selectors
+)
+
+// addLogFlags adds klog's -v/--v verbosity flag to flags.
+func addLogFlags(flags *pflag.FlagSet) {
+	klogFlags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(klogFlags)
+
+	v := pflag.PFlagFromGoFlag(klogFlags.Lookup("v"))
+	v.Shorthand = "v"
+	v.Usage = "Log verbosity: 4 logs every API request with its duration, 5 lookup hits and misses, 6 the resolved selectors"
+	flags.AddFlag(v)
+}
+
+// loggingRoundTripper logs every API request with its status and duration
+// at logRequests verbosity.
+type loggingRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt *loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !klog.V(logRequests).Enabled() {
+		return rt.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		klog.Infof("%s %s failed after %s: %v", req.Method, req.URL.RequestURI(), time.Since(start), err)
+		return resp, err
+	}
+	klog.Infof("%s %s %d in %s", req.Method, req.URL.RequestURI(), resp.StatusCode, time.Since(start))
+	return resp, nil
+}
+
+// logLookup logs whether the lookup of a related object, e.g. a node, was
+// answered by the fetched lists.
+func logLookup(kind, key string, hit bool) {
+	if hit {
+		klog.V(logLookups).Infof("%s %s: lookup hit", kind, key)
+	} else {
+		klog.V(logLookups).Infof("%s %s: lookup miss", kind, key)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkNamespace returns a clear error when the selected namespace doesn't
+// exist, instead of silently listing no pods. Without permission to get
+// namespaces it only warns.
+func (o *Options) checkNamespace(ctx context.Context) error {
+	if o.AllNamespaces || o.Namespace == "" {
+		return nil
+	}
+
+	_, err := withRetry(ctx, o.MaxRetries, func() (*corev1.Namespace, error) {
+		return o.Clientset.CoreV1().Namespaces().Get(ctx, o.Namespace, metav1.GetOptions{})
+	})
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsForbidden(err):
+		fmt.Fprintf(o.ErrOut, "Warning: can't check that namespace %q exists: %v\n", o.Namespace, err)
+		return nil
+	case !apierrors.IsNotFound(err):
+		return newResourceError("get", "namespaces", err)
+	}
+
+	// Suggest similar names if the namespaces can be listed
+	var suggestions []string
+	namespaces, listErr := o.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if listErr == nil {
+		var names []string
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.Name)
+		}
+		suggestions = similarNames(o.Namespace, names)
+	}
+
+	if len(suggestions) > 0 {
+		return fmt.Errorf("namespace %q not found, did you mean %s?", o.Namespace, strings.Join(suggestions, " or "))
+	}
+	return fmt.Errorf("namespace %q not found", o.Namespace)
+}
+
+// similarNames returns the candidates within two edits of name, or that
+// contain it, sorted by name.
+func similarNames(name string, candidates []string) []string {
+	var similar []string
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if editDistance(name, candidate) <= 2 || strings.Contains(candidate, name) {
+			similar = append(similar, fmt.Sprintf("%q", candidate))
+		}
+	}
+	sort.Strings(similar)
+	return similar
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeResources compares a node's capacity and allocatable resources with
+// what the pods scheduled on it request. Allocated also counts the pods
+// under the "pods" resource.
+type NodeResources struct {
+	Name        string              `json:"name"`
+	Capacity    corev1.ResourceList `json:"capacity"`
+	Allocatable corev1.ResourceList `json:"allocatable"`
+	Allocated   corev1.ResourceList `json:"allocated"`
+}
+
+// computeNodeResources sums the requests of the non-terminated pods
+// scheduled on node.
+func computeNodeResources(node *corev1.Node, pods []*corev1.Pod) NodeResources {
+	allocated := corev1.ResourceList{}
+	count := int64(0)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != node.Name {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		addResources(allocated, podRequests(pod))
+		count++
+	}
+	allocated[corev1.ResourcePods] = *resource.NewQuantity(count, resource.DecimalSI)
+
+	return NodeResources{
+		Name:        node.Name,
+		Capacity:    node.Status.Capacity,
+		Allocatable: node.Status.Allocatable,
+		Allocated:   allocated,
+	}
+}
+
+func NewNodesCommand(opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "nodes",
+		Short: "Show node capacity, allocatable and allocated resources",
+		Long: `Show CPU, memory and pod capacity and allocatable resources for every node, plus
+the resources allocated by the requests of the pods scheduled on it. The difference
+between capacity and allocatable is the node's reservation overhead.
+
+Examples:
+  kubectl wider nodes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyEnvDefaults(cmd.Flags()); err != nil {
+				return err
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if err := opts.Complete(); err != nil {
+				return err
+			}
+			return opts.RunNodes()
+		},
+	}
+}
+
+func (o *Options) RunNodes() error {
+	ctx := context.Background()
+
+	if o.ServerVersionCheck {
+		o.checkServerVersion()
+	}
+
+	nodes, err := withRetry(ctx, o.MaxRetries, func() (*corev1.NodeList, error) {
+		return o.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return newResourceError("list", "nodes", err)
+	}
+
+	pods, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PodList, error) {
+		return o.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+			FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+		})
+	})
+	if err != nil {
+		return newResourceError("list", "pods", err)
+	}
+
+	byNode := podsByNode(pods.Items)
+	var summaries []NodeResources
+	for i := range nodes.Items {
+		summaries = append(summaries, computeNodeResources(&nodes.Items[i], byNode[nodes.Items[i].Name]))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	return o.printNodes(summaries)
+}
+
+func (o *Options) printNodes(summaries []NodeResources) error {
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods}
+
+	headers := []string{"NAME"}
+	for _, name := range resources {
+		prefix := strings.ToUpper(string(name))
+		headers = append(headers, prefix+"-CAPACITY", prefix+"-ALLOCATABLE", prefix+"-ALLOCATED")
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, s := range summaries {
+		row := []string{s.Name}
+		for _, name := range resources {
+			row = append(row,
+				formatQuantity(s.Capacity, name),
+				formatQuantity(s.Allocatable, name),
+				formatQuantity(s.Allocated, name),
+			)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return nil
+}
+
+// podsByNode groups the scheduled pods by Spec.NodeName, so that each node
+// only walks its own pods.
+func podsByNode(pods []corev1.Pod) map[string][]*corev1.Pod {
+	byNode := map[string][]*corev1.Pod{}
+	for i := range pods {
+		if name := pods[i].Spec.NodeName; name != "" {
+			byNode[name] = append(byNode[name], &pods[i])
+		}
+	}
+	return byNode
+}
+
+// formatQuantity renders the named resource from list, or <none> when unset.
+func formatQuantity(list corev1.ResourceList, name corev1.ResourceName) string {
+	q, ok := list[name]
+	if !ok {
+		return "<none>"
+	}
+	return q.String()
+}
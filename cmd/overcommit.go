@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// overcommitResources are the resources checked for the OVERCOMMIT column.
+var overcommitResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// overcommitListsAllPods reports whether --with-overcommit needs its own
+// cluster-wide pod list, because the listed pods don't cover every node.
+func (o *Options) overcommitListsAllPods() bool {
+	return !o.AllNamespaces || o.LabelSelector != "" || o.podFieldSelector() != ""
+}
+
+// nodeResources sums the requests of every non-terminated pod per node,
+// once per node. Under -A without pod filters the listed pods are reused,
+// otherwise all pods are listed cluster-wide.
+func (o *Options) nodeResources(ctx context.Context, l *lookups, listed *corev1.PodList) (map[string]*NodeResources, error) {
+	pods := listed
+	if o.overcommitListsAllPods() {
+		var err error
+		pods, err = withRetry(ctx, o.MaxRetries, func() (*corev1.PodList, error) {
+			return o.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+				FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+			})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "pods", err)
+		}
+	}
+
+	byNode := podsByNode(pods.Items)
+	result := make(map[string]*NodeResources, len(l.nodes))
+	for name, node := range l.nodes {
+		nr := computeNodeResources(node, byNode[node.Name])
+		result[name] = &nr
+	}
+	return result, nil
+}
+
+// formatOvercommit lists the resources whose requests on the pod's node
+// exceed its allocatable, with the requested percentage, e.g. "cpu(130%)".
+func formatOvercommit(pn PodWithWider) string {
+	if pn.NodeResources == nil {
+		if pn.Pod.Spec.NodeName != "" && pn.unavailable["nodes"] {
+			return "<unknown>"
+		}
+		return "<none>"
+	}
+
+	var flagged []string
+	for _, name := range overcommitResources {
+		allocatable, ok := pn.NodeResources.Allocatable[name]
+		if !ok || allocatable.IsZero() {
+			continue
+		}
+		allocated := resourceValue(pn.NodeResources.Allocated, name)
+		if allocated.Cmp(allocatable) > 0 {
+			percent := allocated.AsApproximateFloat64() / allocatable.AsApproximateFloat64() * 100
+			flagged = append(flagged, fmt.Sprintf("%s(%.0f%%)", name, percent))
+		}
+	}
+
+	if len(flagged) == 0 {
+		return "<none>"
+	}
+	return strings.Join(flagged, ",")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// revisionAnnotation is set by the Deployment controller on each of its
+// ReplicaSets.
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// Owner identifies a workload controller. Revision is only set for
+// ReplicaSets owned by a Deployment.
+type Owner struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// resolveController follows the pod's controller reference to its
+// top-level controller. Pods managed by a ReplicaSet resolve to the
+// ReplicaSet's own controller (usually a Deployment) when it has one.
+func resolveController(pod *corev1.Pod, replicaSets map[string]*appsv1.ReplicaSet) *Owner {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return nil
+	}
+
+	if ref.Kind == "ReplicaSet" {
+		if rs, ok := replicaSets[pod.Namespace+"/"+ref.Name]; ok {
+			if rsRef := metav1.GetControllerOf(rs); rsRef != nil {
+				return &Owner{Kind: rsRef.Kind, Name: rsRef.Name}
+			}
+		}
+	}
+
+	return &Owner{Kind: ref.Kind, Name: ref.Name}
+}
+
+// resolveReplicaSet returns the ReplicaSet controlling the pod, with its
+// Deployment revision, or nil if the pod isn't managed by a ReplicaSet.
+func resolveReplicaSet(pod *corev1.Pod, replicaSets map[string]*appsv1.ReplicaSet) *Owner {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil || ref.Kind != "ReplicaSet" {
+		return nil
+	}
+
+	owner := &Owner{Kind: ref.Kind, Name: ref.Name}
+	if rs, ok := replicaSets[pod.Namespace+"/"+ref.Name]; ok {
+		owner.Revision = rs.Annotations[revisionAnnotation]
+	}
+	return owner
+}
+
+// findHPA returns the HorizontalPodAutoscaler in namespace whose
+// scaleTargetRef is the given controller.
+func findHPA(hpas []autoscalingv2.HorizontalPodAutoscaler, namespace string, controller *Owner) *autoscalingv2.HorizontalPodAutoscaler {
+	if controller == nil {
+		return nil
+	}
+
+	for i := range hpas {
+		target := hpas[i].Spec.ScaleTargetRef
+		if hpas[i].Namespace == namespace && target.Kind == controller.Kind && target.Name == controller.Name {
+			return &hpas[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// matchingPDBs returns every PodDisruptionBudget in the pod's namespace
+// whose selector matches the pod's labels.
+func matchingPDBs(pod *corev1.Pod, pdbs []policyv1.PodDisruptionBudget) []*policyv1.PodDisruptionBudget {
+	var matched []*policyv1.PodDisruptionBudget
+	for i := range pdbs {
+		if pdbs[i].Namespace != pod.Namespace {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(pdbs[i].Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			matched = append(matched, &pdbs[i])
+		}
+	}
+	return matched
+}
+
+// formatPDBs renders PDBs as name(disruptionsAllowed) pairs.
+func formatPDBs(pdbs []*policyv1.PodDisruptionBudget) string {
+	if len(pdbs) == 0 {
+		return "<none>"
+	}
+
+	var parts []string
+	for _, pdb := range pdbs {
+		parts = append(parts, fmt.Sprintf("%s(%d)", pdb.Name, pdb.Status.DisruptionsAllowed))
+	}
+	return strings.Join(parts, ",")
+}
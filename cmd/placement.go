@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// formatPlacementCheck reports whether the pod's node still satisfies the
+// pod's nodeSelector and required node affinity, which can drift when node
+// labels are edited after scheduling. A failure shows the offending
+// requirement, e.g. "MISMATCH: disktype=ssd". Unscheduled pods show N/A.
+func formatPlacementCheck(pn PodWithWider) string {
+	pod := pn.Pod
+	if pod.Spec.NodeName == "" {
+		return "N/A"
+	}
+	if pn.Node == nil {
+		return pn.missing("nodes")
+	}
+
+	keys := make([]string, 0, len(pod.Spec.NodeSelector))
+	for key := range pod.Spec.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, ok := pn.Node.Labels[key]; !ok || value != pod.Spec.NodeSelector[key] {
+			return fmt.Sprintf("MISMATCH: %s=%s", key, pod.Spec.NodeSelector[key])
+		}
+	}
+
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			if failing, ok := matchNodeSelector(required, pn.Node); !ok {
+				return "MISMATCH: " + failing
+			}
+		}
+	}
+	return "OK"
+}
+
+// matchesNodeSelector reports whether node matches any of the selector's
+// terms.
+func matchesNodeSelector(selector *corev1.NodeSelector, node *corev1.Node) bool {
+	_, ok := matchNodeSelector(selector, node)
+	return ok
+}
+
+// matchNodeSelector reports whether node matches any of the selector's
+// terms and, if not, the first failing requirement of every term joined
+// with " || ". Within a term all expressions and fields must match; a term
+// without any requirement matches nothing, as in the scheduler.
+func matchNodeSelector(selector *corev1.NodeSelector, node *corev1.Node) (string, bool) {
+	var failing []string
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			failing = append(failing, "<empty term>")
+			continue
+		}
+
+		failed := ""
+		for _, req := range term.MatchExpressions {
+			value, ok := node.Labels[req.Key]
+			if !matchesNodeRequirement(req, value, ok) {
+				failed = formatNodeRequirement(req)
+				break
+			}
+		}
+		for _, req := range term.MatchFields {
+			if failed != "" {
+				break
+			}
+			// metadata.name is the only field supported by the scheduler
+			if req.Key != "metadata.name" || !matchesNodeRequirement(req, node.Name, true) {
+				failed = formatNodeRequirement(req)
+			}
+		}
+		if failed == "" {
+			return "", true
+		}
+		failing = append(failing, failed)
+	}
+	return strings.Join(failing, " || "), false
+}
+
+// matchesNodeRequirement evaluates a single requirement against value,
+// where ok tells whether the label is set at all.
+func matchesNodeRequirement(req corev1.NodeSelectorRequirement, value string, ok bool) bool {
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return ok && slices.Contains(req.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !ok || !slices.Contains(req.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return ok
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !ok
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !ok || len(req.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return actual > bound
+		}
+		return actual < bound
+	}
+	return false
+}
+
+// formatNodeRequirement renders a requirement in label selector syntax,
+// e.g. "zone in (a,b)", "!gpu" or "cores>4".
+func formatNodeRequirement(req corev1.NodeSelectorRequirement) string {
+	values := strings.Join(req.Values, ",")
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return fmt.Sprintf("%s in (%s)", req.Key, values)
+	case corev1.NodeSelectorOpNotIn:
+		return fmt.Sprintf("%s notin (%s)", req.Key, values)
+	case corev1.NodeSelectorOpExists:
+		return req.Key
+	case corev1.NodeSelectorOpDoesNotExist:
+		return "!" + req.Key
+	case corev1.NodeSelectorOpGt:
+		return req.Key + ">" + values
+	case corev1.NodeSelectorOpLt:
+		return req.Key + "<" + values
+	}
+	return fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, values)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// requirements records which related resources a run has to fetch. Both
+// the executor in Run() and the --explain-requests planner consult it, so
+// the plan always matches what is actually requested.
+type requirements struct {
+	serviceAccounts bool
+	pvcs            bool
+	pvs             bool
+	replicaSets     bool
+	hpas            bool
+	pdbs            bool
+	events          bool
+	secrets         bool
+	vpas            bool
+	services        bool
+	ingresses       bool
+}
+
+func (o *Options) requirements() requirements {
+	r := requirements{}
+
+	if o.OutputFormat == "json" || o.OutputFormat == "yaml" || o.isJSONPathAsJSON() {
+		r.pvcs = true
+		r.serviceAccounts = true
+	}
+
+	// The tree shows the phase of each PVC; the service account is only
+	// named, so it needs no lookup
+	if o.OutputFormat == "tree" {
+		r.pvcs = true
+	}
+
+	// Paths used by custom columns and --sort-by
+	paths := o.SortBy
+	if o.isCustomColumns() {
+		if _, columnPaths, err := o.parseCustomColumns(); err == nil {
+			paths += "," + strings.Join(columnPaths, ",")
+		}
+	}
+
+	if strings.Contains(paths, ".sa") || strings.Contains(paths, ".serviceAccount") {
+		r.serviceAccounts = true
+	}
+
+	if strings.Contains(paths, ".pvc") || strings.Contains(paths, ".pvcs") {
+		r.pvcs = true
+	}
+
+	if o.WithHPA {
+		r.replicaSets = true
+		r.hpas = true
+	}
+
+	if o.WithOwners {
+		r.replicaSets = true
+	}
+
+	if o.WithPDB {
+		r.pdbs = true
+	}
+
+	if o.WithPV {
+		r.pvcs = true
+		r.pvs = true
+	}
+
+	if o.WithEvents {
+		r.events = true
+	}
+
+	if o.WithPullSecrets {
+		r.serviceAccounts = true
+		r.secrets = true
+	}
+
+	if o.WithVPA {
+		r.replicaSets = true
+		r.vpas = true
+	}
+
+	if o.WithIngress {
+		r.services = true
+		r.ingresses = true
+	}
+
+	return r
+}
+
+// apiRequest is a single kind of API call a run would make.
+type apiRequest struct {
+	verb     string
+	group    string
+	resource string
+	scope    string
+	note     string
+}
+
+// plannedRequests lists the API calls the current flags would trigger.
+// With --diff, the requests are made against both contexts, and the
+// server version is only checked on the current one.
+func (o *Options) plannedRequests() []apiRequest {
+	if !o.Diff {
+		return o.contextRequests()
+	}
+
+	other := o.contextBOptions()
+	other.ServerVersionCheck = false
+
+	var requests []apiRequest
+	for _, side := range []struct {
+		label    string
+		requests []apiRequest
+	}{
+		{o.contextLabel(), o.contextRequests()},
+		{o.ContextB, other.contextRequests()},
+	} {
+		for _, req := range side.requests {
+			if req.note == "" {
+				req.note = "context " + side.label
+			} else {
+				req.note = "context " + side.label + ", " + req.note
+			}
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// contextRequests lists the API calls the current flags would trigger
+// against a single context.
+func (o *Options) contextRequests() []apiRequest {
+	ns := o.Namespace
+	if o.AllNamespaces {
+		ns = "<all>"
+	}
+
+	podsNote := ""
+	if selector := o.podFieldSelector(); selector != "" {
+		podsNote = "field selector " + selector
+	}
+
+	var requests []apiRequest
+	if o.ServerVersionCheck {
+		requests = append(requests, apiRequest{verb: "get", group: "", resource: "/version", scope: "<cluster>", note: "non-resource URL"})
+	}
+	if !o.AllNamespaces && o.Namespace != "" {
+		requests = append(requests, apiRequest{verb: "get", group: "", resource: "namespaces", scope: "<cluster>", note: "checks that " + o.Namespace + " exists"})
+	}
+
+	if o.SelectorFromPod != "" {
+		requests = append(requests, apiRequest{verb: "get", group: "", resource: "pods", scope: o.Namespace, note: "reads the labels of " + o.SelectorFromPod})
+	}
+
+	if o.isWorkloadResource() {
+		return append(requests,
+			apiRequest{verb: "list", group: "apps", resource: o.Resource, scope: ns},
+			apiRequest{verb: "list", group: "", resource: "pods", scope: ns, note: "to place the workloads' pods on nodes"},
+		)
+	}
+
+	if o.Count {
+		return append(requests, apiRequest{verb: "list", group: "", resource: "pods", scope: ns, note: podsNote})
+	}
+
+	r := o.requirements()
+	requests = append(requests,
+		apiRequest{verb: "list", group: "", resource: "nodes", scope: "<cluster>"},
+		apiRequest{verb: "list", group: "", resource: "pods", scope: ns, note: podsNote},
+	)
+
+	if r.pvcs {
+		requests = append(requests,
+			apiRequest{verb: "list", group: "", resource: "persistentvolumeclaims", scope: ns},
+			apiRequest{verb: "get", group: "", resource: "persistentvolumeclaims", scope: ns, note: "fallback for claims missing from the list"},
+		)
+	}
+	if r.pvs {
+		requests = append(requests, apiRequest{verb: "list", group: "", resource: "persistentvolumes", scope: "<cluster>"})
+	}
+	if r.serviceAccounts {
+		requests = append(requests,
+			apiRequest{verb: "list", group: "", resource: "serviceaccounts", scope: ns},
+			apiRequest{verb: "get", group: "", resource: "serviceaccounts", scope: ns, note: "fallback for accounts missing from the list"},
+		)
+	}
+	if r.replicaSets {
+		requests = append(requests, apiRequest{verb: "list", group: "apps", resource: "replicasets", scope: ns})
+	}
+	if r.hpas {
+		requests = append(requests, apiRequest{verb: "list", group: "autoscaling", resource: "horizontalpodautoscalers", scope: ns})
+	}
+	if r.pdbs {
+		requests = append(requests, apiRequest{verb: "list", group: "policy", resource: "poddisruptionbudgets", scope: ns})
+	}
+	if r.events {
+		requests = append(requests, apiRequest{verb: "list", group: "", resource: "events", scope: ns, note: "field selector involvedObject.kind=Pod"})
+	}
+	if r.secrets {
+		requests = append(requests, apiRequest{verb: "list", group: "", resource: "secrets", scope: ns, note: "metadata only, no secret data"})
+	}
+	if r.services {
+		requests = append(requests, apiRequest{verb: "list", group: "", resource: "services", scope: ns})
+	}
+	if r.ingresses {
+		requests = append(requests, apiRequest{verb: "list", group: "networking.k8s.io", resource: "ingresses", scope: ns})
+	}
+	if r.vpas {
+		requests = append(requests, apiRequest{verb: "list", group: "autoscaling.k8s.io", resource: "verticalpodautoscalers", scope: ns, note: "skipped if the CRD isn't installed"})
+	}
+	if o.WithOvercommit && o.overcommitListsAllPods() {
+		requests = append(requests, apiRequest{verb: "list", group: "", resource: "pods", scope: "<all>", note: "sums the requests on each node"})
+	}
+	if o.Watch {
+		note := ""
+		if o.Resync > 0 {
+			note = "nodes and the other lookups are re-listed every " + o.Resync.String()
+		}
+		requests = append(requests, apiRequest{verb: "watch", group: "", resource: "pods", scope: ns, note: note})
+		requests = append(requests, apiRequest{verb: "get", group: "", resource: "nodes", scope: "<cluster>", note: "fallback for nodes added since they were listed"})
+	}
+
+	return requests
+}
+
+// printPlan prints the API calls the current flags would trigger, to help
+// craft a minimal Role for the plugin.
+func (o *Options) printPlan() error {
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "VERB\tAPI-GROUP\tRESOURCE\tNAMESPACE\tNOTE")
+	for _, req := range o.plannedRequests() {
+		group := req.group
+		if group == "" {
+			group = `""`
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", req.verb, group, req.resource, req.scope, req.note)
+	}
+
+	return nil
+}
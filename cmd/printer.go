@@ -4,30 +4,222 @@ import (
 	"encoding/json"
 	"fmt"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"os"
 	"sigs.k8s.io/yaml"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
-func (o *Options) printJSON(podNodes []PodWithWider) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(podNodes)
-}
+// printStructured prints pods or workloads for -o json and -o yaml. Pods
+// are projected onto --schema first.
+func (o *Options) printStructured(objects []WiderObject) error {
+	o.stripManagedFields(objects)
+
+	projected := make([]interface{}, 0, len(objects))
+	for _, obj := range objects {
+		if pn, ok := obj.(PodWithWider); ok {
+			projected = append(projected, o.projectPod(pn))
+		} else {
+			projected = append(projected, obj)
+		}
+	}
+
+	if o.OutputFormat == "json" {
+		encoder := json.NewEncoder(o.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(projected)
+	}
 
-func (o *Options) printYAML(podNodes []PodWithWider) error {
-	data, err := yaml.Marshal(podNodes)
+	if o.YAMLStream {
+		// One document per object, like kubectl get -o yaml for a stream
+		for i, obj := range projected {
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return fmt.Errorf("failed to marshal to YAML: %w", err)
+			}
+			if i > 0 {
+				fmt.Fprintln(o.Out, "---")
+			}
+			fmt.Fprint(o.Out, string(data))
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(projected)
 	if err != nil {
 		return fmt.Errorf("failed to marshal to YAML: %w", err)
 	}
-	fmt.Println(string(data))
+	fmt.Fprintln(o.Out, string(data))
+	return nil
+}
+
+// CompactPod is the small, stable per-pod schema printed by -o json-compact.
+type CompactPod struct {
+	Name           string   `json:"name"`
+	Namespace      string   `json:"namespace"`
+	Node           string   `json:"node"`
+	NodeIP         string   `json:"nodeIP"`
+	ServiceAccount string   `json:"serviceAccount"`
+	PVCs           []string `json:"pvcs"`
+	Phase          string   `json:"phase"`
+	QOSClass       string   `json:"qosClass"`
+}
+
+// compactPod projects pn onto the json-compact schema. PVC names come from
+// the pod's volumes, so claims that couldn't be fetched are still listed.
+func compactPod(pn PodWithWider) CompactPod {
+	pod := pn.Pod
+	compact := CompactPod{
+		Name:           pod.Name,
+		Namespace:      pod.Namespace,
+		Node:           pod.Spec.NodeName,
+		ServiceAccount: pod.Spec.ServiceAccountName,
+		PVCs:           []string{},
+		Phase:          string(pod.Status.Phase),
+		QOSClass:       string(pod.Status.QOSClass),
+	}
+	if pn.Node != nil {
+		compact.NodeIP = nodeInternalIP(pn.Node)
+	}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			compact.PVCs = append(compact.PVCs, vol.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return compact
+}
+
+// printJSONCompact writes one compact JSON object per line.
+func (o *Options) printJSONCompact(podNodes []PodWithWider) error {
+	encoder := json.NewEncoder(o.Out)
+	for _, pn := range podNodes {
+		if err := encoder.Encode(compactPod(pn)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// stripManagedFields drops metadata.managedFields from every embedded
+// object unless --show-managed-fields is set, like kubectl does by default.
+func (o *Options) stripManagedFields(objects []WiderObject) {
+	if o.ShowManagedFields {
+		return
+	}
+
+	for _, wo := range objects {
+		for _, obj := range wo.Objects() {
+			obj.SetManagedFields(nil)
+		}
+	}
+}
+
 func (o *Options) printCustomColumns(podNodes []PodWithWider) error {
+	headers, paths, err := o.parseCustomColumns()
+	if err != nil {
+		return err
+	}
+
+	// Render all rows first, counting the rows where each column resolved
+	rows := make([][]string, 0, len(podNodes))
+	hits := make([]int, len(paths))
+	for _, pn := range podNodes {
+		row := customColumnsRow(pn, paths)
+		for i, val := range row {
+			if val != "<none>" && val != "" {
+				hits[i]++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if err := o.checkEmptyColumns(headers, paths, hits, len(rows)); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	// Print headers
+	if !o.NoHeaders {
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+
+	// Print rows
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return nil
+}
+
+// checkEmptyColumns warns about columns that resolved to nothing for every
+// row, which usually means a typo in the path. With --strict-columns it
+// returns an error instead.
+func (o *Options) checkEmptyColumns(headers, paths []string, hits []int, rows int) error {
+	if rows == 0 {
+		return nil
+	}
+
+	for i, count := range hits {
+		if count > 0 {
+			continue
+		}
+		if o.StrictColumns {
+			return fmt.Errorf("column %s (%s) is empty for every pod, check the path for typos", headers[i], paths[i])
+		}
+		fmt.Fprintf(o.ErrOut, "Warning: column %s (%s) is empty for every pod, check the path for typos (use --strict-columns to fail instead)\n", headers[i], paths[i])
+	}
+	return nil
+}
+
+// outputFormats are the supported -o values, in the order help and error
+// messages list them. Values ending in "=" take an argument.
+var outputFormats = []string{"wide", "json", "json-compact", "yaml", "html", "tree", "custom-columns=", "custom-columns-file=", "jsonpath-as-json="}
+
+// supportedOutputFormats returns outputFormats for display, e.g.
+// "custom-columns=...".
+func supportedOutputFormats() []string {
+	var formats []string
+	for _, format := range outputFormats {
+		if strings.HasSuffix(format, "=") {
+			format += "..."
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// isSupportedOutputFormat reports whether format is one of outputFormats,
+// or starts with one that takes an argument.
+func isSupportedOutputFormat(format string) bool {
+	for _, supported := range outputFormats {
+		if format == supported || (strings.HasSuffix(supported, "=") && strings.HasPrefix(format, supported)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) isCustomColumns() bool {
+	return strings.HasPrefix(o.OutputFormat, "custom-columns=") || strings.HasPrefix(o.OutputFormat, "custom-columns-file=")
+}
+
+func (o *Options) parseCustomColumns() ([]string, []string, error) {
+	if strings.HasPrefix(o.OutputFormat, "custom-columns-file=") {
+		return parseCustomColumnsFile(strings.TrimPrefix(o.OutputFormat, "custom-columns-file="))
+	}
+
 	// Parse custom-columns format
 	columnsStr := strings.TrimPrefix(o.OutputFormat, "custom-columns=")
+	return parseColumnSpec(columnsStr)
+}
+
+func parseColumnSpec(columnsStr string) ([]string, []string, error) {
 	columnDefs := strings.Split(columnsStr, ",")
 
 	var headers []string
@@ -36,106 +228,335 @@ func (o *Options) printCustomColumns(podNodes []PodWithWider) error {
 	for _, def := range columnDefs {
 		parts := strings.SplitN(def, ":", 2)
 		if len(parts) != 2 {
-			return fmt.Errorf("invalid custom-columns format: %s", def)
+			return nil, nil, fmt.Errorf("invalid custom-columns format: %s", def)
 		}
 		headers = append(headers, parts[0])
 		paths = append(paths, parts[1])
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
+	return headers, paths, nil
+}
 
-	// Print headers
-	fmt.Fprintln(w, strings.Join(headers, "\t"))
+// parseCustomColumnsFile reads NAME:.path column specs from a file, one or
+// more comma-separated specs per line. Blank lines and lines starting with
+// # are ignored.
+func parseCustomColumnsFile(path string) ([]string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read custom-columns file: %w", err)
+	}
 
-	// Print rows
-	for _, pn := range podNodes {
-		var values []string
-		for _, path := range paths {
-			val, err := getValueByPath(pn, path)
-			if err != nil {
-				values = append(values, "<none>")
-			} else {
-				values = append(values, val)
-			}
+	var headers []string
+	var paths []string
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lineHeaders, linePaths, err := parseColumnSpec(strings.TrimSuffix(line, ","))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
 		}
-		fmt.Fprintln(w, strings.Join(values, "\t"))
+		headers = append(headers, lineHeaders...)
+		paths = append(paths, linePaths...)
 	}
 
-	return nil
+	if len(headers) == 0 {
+		return nil, nil, fmt.Errorf("custom-columns file %s contains no columns", path)
+	}
+
+	return headers, paths, nil
+}
+
+func customColumnsRow(pn PodWithWider, paths []string) []string {
+	var values []string
+	for _, path := range paths {
+		val, err := getValueByPath(pn, path)
+		if err != nil {
+			values = append(values, "<none>")
+		} else {
+			values = append(values, val)
+		}
+	}
+	return values
 }
 
 func (o *Options) printDefault(podNodes []PodWithWider) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
 	defer w.Flush()
 
-	if o.AllNamespaces {
-		fmt.Fprintln(w, "NAMESPACE\tNAME\tREADY\tSTATUS\tRESTARTS\tAGE\tIP\tNODE")
-	} else {
-		fmt.Fprintln(w, "NAME\tREADY\tSTATUS\tRESTARTS\tAGE\tIP\tNODE")
+	headers := o.tableHeaders()
+	if !o.NoHeaders {
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
 	}
 
+	var rows [][]string
 	for _, pn := range podNodes {
-		pod := pn.Pod
-
-		// Calculate READY (ready/total containers)
-		totalContainers := len(pod.Spec.Containers)
-		readyContainers := 0
-		for _, cs := range pod.Status.ContainerStatuses {
-			if cs.Ready {
-				readyContainers++
-			}
-		}
-		ready := fmt.Sprintf("%d/%d", readyContainers, totalContainers)
+		rows = append(rows, o.tableRows(pn)...)
+	}
+	o.truncateTable(headers, rows)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return nil
+}
 
-		// Get STATUS
-		status := string(pod.Status.Phase)
-		if pod.DeletionTimestamp != nil {
-			status = "Terminating"
+// tableHeaders returns the headers of the default table, or of the
+// per-container table under --by-container.
+func (o *Options) tableHeaders() []string {
+	if o.ByContainer {
+		return o.containerHeaders()
+	}
+	return o.defaultHeaders()
+}
+
+// tableRows returns the pod's row of the default table, or one row per
+// container under --by-container.
+func (o *Options) tableRows(pn PodWithWider) [][]string {
+	if o.ByContainer {
+		return o.containerRows(pn)
+	}
+	return [][]string{o.defaultRow(pn)}
+}
+
+// isWide reports whether the default columns are extended with the -o wide
+// ones. The html output always includes them.
+func (o *Options) isWide() bool {
+	return o.OutputFormat == "wide" || o.OutputFormat == "html"
+}
+
+func (o *Options) defaultHeaders() []string {
+	headers := []string{"NAME", "READY", "STATUS", "RESTARTS", "AGE", "IP", "NODE"}
+	if o.isWide() {
+		headers = append(headers, "POD-IP", "HOST-IP", "READY-SINCE", "SCHED-STATUS", "PLACEMENT-OK", "SCHEDULER", "PREEMPTION", "TERMINATING-FOR")
+		if o.WithOwners {
+			headers = append(headers, "REVISION")
+		}
+		if o.WithPDB {
+			headers = append(headers, "PDB")
+		}
+		if o.WithPV {
+			headers = append(headers, "PV-RECLAIM", "PV-PHASE", "PV-CAPACITY", "VOLUME-AFFINITY-OK")
+		}
+		if o.WithEvents {
+			headers = append(headers, "EVENTS")
+		}
+		if o.WithOvercommit {
+			headers = append(headers, "OVERCOMMIT")
+		}
+		if o.WithPullSecrets {
+			headers = append(headers, "PULL-SECRETS")
 		}
+		if o.WithVPA {
+			headers = append(headers, "RECOMMENDED")
+		}
+		if o.WithIngress {
+			headers = append(headers, "EXTERNAL")
+		}
+	}
+	for _, name := range o.extendedColumns {
+		upper := strings.ToUpper(string(name))
+		headers = append(headers, upper, "NODE-"+upper)
+	}
+	if o.ShowLabels {
+		headers = append(headers, "LABELS")
+	}
+	if o.ShowAnnotations {
+		headers = append(headers, "ANNOTATIONS")
+	}
+	for _, key := range o.Annotations {
+		headers = append(headers, annotationHeader(key))
+	}
+	if o.AllNamespaces {
+		headers = append([]string{"NAMESPACE"}, headers...)
+	}
+	return headers
+}
+
+func (o *Options) defaultRow(pn PodWithWider) []string {
+	pod := pn.Pod
 
-		// Calculate RESTARTS
-		restarts := 0
-		for _, cs := range pod.Status.ContainerStatuses {
-			restarts += int(cs.RestartCount)
+	// Calculate READY (ready/total containers)
+	totalContainers := len(pod.Spec.Containers)
+	readyContainers := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			readyContainers++
 		}
+	}
+	ready := fmt.Sprintf("%d/%d", readyContainers, totalContainers)
+
+	// Get STATUS
+	status := string(pod.Status.Phase)
+	if pod.DeletionTimestamp != nil {
+		status = "Terminating"
+	}
+
+	// Calculate RESTARTS
+	restarts := podRestarts(pod)
+
+	// Calculate AGE
+	age := formatAge(pod.CreationTimestamp)
 
-		// Calculate AGE
-		age := formatAge(pod.CreationTimestamp)
+	// Get NODE info
+	nodeName := pod.Spec.NodeName
+	nodeIP := ""
+	if pn.Node == nil && pn.Pod.Spec.NodeName != "" && pn.unavailable["nodes"] {
+		nodeIP = "<unknown>"
+	} else if pn.Node != nil {
+		nodeIP = nodeInternalIP(pn.Node)
+	}
 
-		// Get NODE info
-		nodeName := pod.Spec.NodeName
-		nodeIP := ""
-		if pn.Node != nil {
-			for _, addr := range pn.Node.Status.Addresses {
-				if addr.Type == corev1.NodeInternalIP {
-					nodeIP = addr.Address
-					break
-				}
+	row := []string{
+		pod.Name,
+		ready,
+		status,
+		strconv.Itoa(restarts),
+		age,
+		nodeIP,
+		nodeName,
+	}
+	if o.isWide() {
+		row = append(row, valueOrNone(pod.Status.PodIP), valueOrNone(pod.Status.HostIP), formatReadySince(pod), formatSchedulingStatus(pod), formatPlacementCheck(pn))
+		row = append(row, o.formatSchedulerName(pod), o.formatPreemptionPolicy(pod), formatTerminatingFor(pod, time.Now()))
+		if o.WithOwners {
+			revision := ""
+			if pn.ReplicaSet != nil {
+				revision = pn.ReplicaSet.Revision
 			}
+			row = append(row, valueOrNone(revision))
 		}
-
-		if o.AllNamespaces {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-				pod.Namespace,
-				pod.Name,
-				ready,
-				status,
-				restarts,
-				age,
-				nodeIP,
-				nodeName)
-		} else {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-				pod.Name,
-				ready,
-				status,
-				restarts,
-				age,
-				nodeIP,
-				nodeName)
+		if o.WithPDB {
+			row = append(row, formatPDBs(pn.PDBs))
+		}
+		if o.WithPV {
+			row = append(row,
+				formatPVs(pn.PVs, pvReclaimPolicy),
+				formatPVs(pn.PVs, pvPhase),
+				formatPVs(pn.PVs, pvCapacity),
+				formatVolumeAffinity(pn),
+			)
+		}
+		if o.WithEvents {
+			row = append(row, formatLatestEvent(pn.Events))
 		}
+		if o.WithOvercommit {
+			row = append(row, formatOvercommit(pn))
+		}
+		if o.WithPullSecrets {
+			row = append(row, formatPullSecrets(pn))
+		}
+		if o.WithVPA {
+			row = append(row, formatVPA(pn.VPA))
+		}
+		if o.WithIngress {
+			row = append(row, formatExternal(pn))
+		}
+	}
+	for _, name := range o.extendedColumns {
+		request := resourceValue(pn.Requests, name)
+		row = append(row, request.String(), formatNodeAllocatable(pn, name))
+	}
+	if o.ShowLabels {
+		row = append(row, formatLabels(pod.Labels))
 	}
+	if o.ShowAnnotations {
+		row = append(row, formatAnnotations(pod.Annotations))
+	}
+	for _, key := range o.Annotations {
+		row = append(row, valueOrNone(singleLine(pod.Annotations[key])))
+	}
+	if o.AllNamespaces {
+		row = append([]string{pod.Namespace}, row...)
+	}
+	return row
+}
 
-	return nil
+// formatAnnotations renders annotations as key=value pairs sorted by key
+// and joined with commas. Values are folded onto a single line.
+func formatAnnotations(annotations map[string]string) string {
+	if len(annotations) == 0 {
+		return "<none>"
+	}
+
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+singleLine(annotations[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// annotationHeader returns the column header for an --annotation key: its
+// name without the prefix, in upper case, like kubectl -L does for labels.
+func annotationHeader(key string) string {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		key = key[i+1:]
+	}
+	return strings.ToUpper(key)
+}
+
+// singleLine collapses whitespace, including newlines, into single spaces
+// so multi-line values don't break the table.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// formatLabels renders labels like kubectl --show-labels, as key=value
+// pairs sorted by key and joined with commas.
+func formatLabels(podLabels map[string]string) string {
+	if len(podLabels) == 0 {
+		return "<none>"
+	}
+	return labels.Set(podLabels).String()
+}
+
+// formatReadySince renders how long the pod has been in its current ready
+// state, from the Ready condition's last transition.
+func formatReadySince(pod *corev1.Pod) string {
+	cond := podReadyCondition(pod)
+	if cond == nil || cond.LastTransitionTime.IsZero() {
+		return "<none>"
+	}
+	return formatAge(cond.LastTransitionTime)
+}
+
+// formatTerminatingFor shows how long ago the pod's deletion was requested,
+// with its grace period, e.g. "12m (grace 30s)". The deletionTimestamp is
+// set to the request time plus the grace period, so pods still around
+// after it are marked overdue. Pods not being deleted show <none>.
+func formatTerminatingFor(pod *corev1.Pod, now time.Time) string {
+	if pod.DeletionTimestamp == nil {
+		return "<none>"
+	}
+
+	grace := time.Duration(0)
+	if pod.DeletionGracePeriodSeconds != nil {
+		grace = time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+	}
+	requested := pod.DeletionTimestamp.Add(-grace)
+
+	formatted := fmt.Sprintf("%s (grace %s)", formatDuration(now.Sub(requested)), formatDuration(grace))
+	if now.After(pod.DeletionTimestamp.Time) {
+		formatted += " overdue"
+	}
+	return formatted
+}
+
+// nodeInternalIP returns the node's InternalIP address, or an empty string.
+func nodeInternalIP(node *corev1.Node) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+	return ""
 }
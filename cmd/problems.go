@@ -0,0 +1,56 @@
+package main
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podRestarts sums the restarts of the pod's containers.
+func podRestarts(pod *corev1.Pod) int {
+	restarts := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += int(cs.RestartCount)
+	}
+	return restarts
+}
+
+// isNodeReady reports whether the node's Ready condition is True.
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hasProblem reports whether the pod is kept by --only-problems: it isn't
+// Running or Succeeded, it restarted more than --restart-threshold times,
+// it is terminating, or its node is NotReady or gone. Nodes that couldn't
+// be listed or fetched aren't held against the pod.
+func (o *Options) hasProblem(pn PodWithWider) bool {
+	pod := pn.Pod
+	if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodSucceeded {
+		return true
+	}
+	if podRestarts(pod) > o.RestartThreshold {
+		return true
+	}
+	if pod.DeletionTimestamp != nil {
+		return true
+	}
+	if pod.Spec.NodeName != "" && !pn.unavailable["nodes"] {
+		return pn.Node == nil || !isNodeReady(pn.Node)
+	}
+	return false
+}
+
+// keepProblems returns the pods with a problem, in order.
+func (o *Options) keepProblems(podNodes []PodWithWider) []PodWithWider {
+	var kept []PodWithWider
+	for _, pn := range podNodes {
+		if o.hasProblem(pn) {
+			kept = append(kept, pn)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PullSecret is an image pull secret referenced by a pod, directly or
+// through its service account, and whether it exists in the namespace.
+type PullSecret struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Found  bool   `json:"found"`
+}
+
+// listSecretNames lists the names of the secrets in ns, keyed by
+// namespace/name. Only metadata is requested, so secret data is never
+// transferred.
+func (o *Options) listSecretNames(ctx context.Context, ns string) (map[string]bool, error) {
+	secrets, err := withRetry(ctx, o.MaxRetries, func() (*metav1.PartialObjectMetadataList, error) {
+		return o.Metadata.Resource(corev1.SchemeGroupVersion.WithResource("secrets")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, newResourceError("list", "secrets", err)
+	}
+
+	names := make(map[string]bool, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		names[secret.Namespace+"/"+secret.Name] = true
+	}
+	return names, nil
+}
+
+// resolvePullSecrets returns the pod's image pull secrets followed by those
+// of its service account, each once, and whether they exist.
+func resolvePullSecrets(pod *corev1.Pod, sa *corev1.ServiceAccount, secrets map[string]bool) []PullSecret {
+	var resolved []PullSecret
+	seen := map[string]bool{}
+	add := func(refs []corev1.LocalObjectReference, source string) {
+		for _, ref := range refs {
+			if ref.Name == "" || seen[ref.Name] {
+				continue
+			}
+			seen[ref.Name] = true
+			resolved = append(resolved, PullSecret{
+				Name:   ref.Name,
+				Source: source,
+				Found:  secrets[pod.Namespace+"/"+ref.Name],
+			})
+		}
+	}
+
+	add(pod.Spec.ImagePullSecrets, "pod")
+	if sa != nil {
+		add(sa.ImagePullSecrets, "serviceAccount")
+	}
+	return resolved
+}
+
+// formatPullSecrets shows OK when every pull secret exists, and the missing
+// ones otherwise, e.g. "missing: regcred". When the pod's service account
+// couldn't be read its pull secrets are unknown, so <unknown> is shown
+// instead of OK.
+func formatPullSecrets(pn PodWithWider) string {
+	var missing []string
+	for _, secret := range pn.PullSecrets {
+		if !secret.Found {
+			missing = append(missing, secret.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return "missing: " + strings.Join(missing, ",")
+	}
+	if pn.Pod.Spec.ServiceAccountName != "" && pn.ServiceAccount == nil && pn.unavailable["serviceaccounts"] {
+		return "<unknown>"
+	}
+	if len(pn.PullSecrets) == 0 {
+		return "<none>"
+	}
+	return "OK"
+}
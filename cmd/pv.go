@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// boundPVs returns the PersistentVolume bound to each of pvcs, in the same
+// order. Entries are nil for claims that are Pending or otherwise unbound.
+func boundPVs(pvcs []*corev1.PersistentVolumeClaim, pvs map[string]*corev1.PersistentVolume) []*corev1.PersistentVolume {
+	var bound []*corev1.PersistentVolume
+	for _, pvc := range pvcs {
+		var pv *corev1.PersistentVolume
+		if pvc.Status.Phase == corev1.ClaimBound && pvc.Spec.VolumeName != "" {
+			pv = pvs[pvc.Spec.VolumeName]
+		}
+		bound = append(bound, pv)
+	}
+	return bound
+}
+
+// formatPVs renders field for every PV, showing <unbound> for claims
+// without one.
+func formatPVs(pvs []*corev1.PersistentVolume, field func(pv *corev1.PersistentVolume) string) string {
+	if len(pvs) == 0 {
+		return "<none>"
+	}
+
+	var values []string
+	for _, pv := range pvs {
+		if pv == nil {
+			values = append(values, "<unbound>")
+			continue
+		}
+		values = append(values, valueOrNone(field(pv)))
+	}
+	return strings.Join(values, ",")
+}
+
+func pvReclaimPolicy(pv *corev1.PersistentVolume) string {
+	return string(pv.Spec.PersistentVolumeReclaimPolicy)
+}
+
+func pvPhase(pv *corev1.PersistentVolume) string {
+	return string(pv.Status.Phase)
+}
+
+func pvCapacity(pv *corev1.PersistentVolume) string {
+	q, ok := pv.Spec.Capacity[corev1.ResourceStorage]
+	if !ok {
+		return ""
+	}
+	return q.String()
+}
+
+func pvName(pv *corev1.PersistentVolume) string {
+	return pv.Name
+}
+
+// formatVolumeAffinity reports, per bound PV, whether the pod's node
+// satisfies the PV's node affinity. PVs without node affinity show N/A and
+// pods that aren't scheduled yet show <none>.
+func formatVolumeAffinity(pn PodWithWider) string {
+	if pn.Node == nil && pn.Pod.Spec.NodeName != "" && pn.unavailable["nodes"] {
+		return "<unknown>"
+	}
+	if len(pn.PVs) == 0 || pn.Node == nil {
+		return "<none>"
+	}
+
+	return formatPVs(pn.PVs, func(pv *corev1.PersistentVolume) string {
+		if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			return "N/A"
+		}
+		return strconv.FormatBool(matchesNodeSelector(pv.Spec.NodeAffinity.Required, pn.Node))
+	})
+}
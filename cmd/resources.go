@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// podRequests returns the effective resource requests of a pod: the sum of
+// its containers, raised to the largest init container where that is
+// higher, plus the pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	return podResources(pod, func(r corev1.ResourceRequirements) corev1.ResourceList {
+		return r.Requests
+	})
+}
+
+// podLimits returns the effective resource limits of a pod, computed the
+// same way as podRequests.
+func podLimits(pod *corev1.Pod) corev1.ResourceList {
+	return podResources(pod, func(r corev1.ResourceRequirements) corev1.ResourceList {
+		return r.Limits
+	})
+}
+
+func podResources(pod *corev1.Pod, get func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		addResources(total, get(c.Resources))
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		for name, q := range get(c.Resources) {
+			if current, ok := total[name]; !ok || q.Cmp(current) > 0 {
+				total[name] = q.DeepCopy()
+			}
+		}
+	}
+
+	addResources(total, pod.Spec.Overhead)
+	return total
+}
+
+func addResources(total, add corev1.ResourceList) {
+	for name, q := range add {
+		current := total[name]
+		current.Add(q)
+		total[name] = current
+	}
+}
+
+// resourceValue returns the named resource from list, or zero when unset.
+func resourceValue(list corev1.ResourceList, name corev1.ResourceName) resource.Quantity {
+	if q, ok := list[name]; ok {
+		return q
+	}
+	return resource.Quantity{}
+}
+
+// defaultExtendedResource is shown under -o wide without --extended-resource
+// when any pod requests it or any of their nodes offers it.
+const defaultExtendedResource corev1.ResourceName = "nvidia.com/gpu"
+
+// isExtendedResourceName reports whether name is a domain-prefixed resource
+// outside the kubernetes.io domain, such as nvidia.com/gpu.
+func isExtendedResourceName(name string) bool {
+	domain, _, ok := strings.Cut(name, "/")
+	if !ok || domain == "" {
+		return false
+	}
+	return domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// extendedResourceColumns returns the extended resources to show as
+// columns: the one from --extended-resource, or nvidia.com/gpu under
+// -o wide when it is present on any of the pods or their nodes.
+func (o *Options) extendedResourceColumns(podNodes []PodWithWider) []corev1.ResourceName {
+	if o.ExtendedResource != "" {
+		return []corev1.ResourceName{corev1.ResourceName(o.ExtendedResource)}
+	}
+	if !o.isWide() {
+		return nil
+	}
+
+	for _, pn := range podNodes {
+		if _, ok := pn.Requests[defaultExtendedResource]; ok {
+			return []corev1.ResourceName{defaultExtendedResource}
+		}
+		if pn.Node != nil {
+			if _, ok := pn.Node.Status.Allocatable[defaultExtendedResource]; ok {
+				return []corev1.ResourceName{defaultExtendedResource}
+			}
+		}
+	}
+	return nil
+}
+
+// formatNodeAllocatable renders the node's allocatable amount of name, or
+// <none> when the node doesn't offer it.
+func formatNodeAllocatable(pn PodWithWider, name corev1.ResourceName) string {
+	if pn.Node == nil {
+		if pn.Pod.Spec.NodeName != "" && pn.unavailable["nodes"] {
+			return "<unknown>"
+		}
+		return "<none>"
+	}
+	q, ok := pn.Node.Status.Allocatable[name]
+	if !ok {
+		return "<none>"
+	}
+	return q.String()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// retryBaseDelay is the delay before the first retry. It doubles on every
+// further retry up to retryMaxDelay.
+var (
+	retryBaseDelay = 250 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
+
+// withRetry calls fn until it succeeds, fails with an error that isn't
+// transient, or maxRetries retries have been made. Cancelling ctx stops
+// waiting for the next attempt.
+func withRetry[T any](ctx context.Context, maxRetries int, fn func() (T, error)) (T, error) {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= maxRetries || !isRetriable(err) {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
+
+// isRetriable reports whether err is transient: a network failure, a 429
+// or a 5xx. Client errors such as 403 and 404 are never retried.
+func isRetriable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		code := status.Status().Code
+		return code == 429 || code >= 500
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err) ||
+		utilnet.IsTimeout(err)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// formatSchedulingStatus explains why a pod isn't scheduled yet: its
+// scheduling gates and the reason of its PodScheduled=False condition, e.g.
+// "gated: example.com/quota" or "Unschedulable: 0/3 nodes are available".
+// Scheduled pods show <scheduled>.
+func formatSchedulingStatus(pod *corev1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return "<scheduled>"
+	}
+
+	var parts []string
+	if len(pod.Spec.SchedulingGates) > 0 {
+		var gates []string
+		for _, gate := range pod.Spec.SchedulingGates {
+			gates = append(gates, gate.Name)
+		}
+		parts = append(parts, "gated: "+strings.Join(gates, ","))
+	}
+
+	// The SchedulingGated reason only repeats the gates
+	if cond := podScheduledCondition(pod); cond != nil && cond.Status == corev1.ConditionFalse && cond.Reason != corev1.PodReasonSchedulingGated {
+		reason := valueOrNone(cond.Reason)
+		if cond.Message != "" {
+			reason += ": " + singleLine(cond.Message)
+		}
+		parts = append(parts, reason)
+	}
+
+	if len(parts) == 0 {
+		return "<none>"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatSchedulerName returns the scheduler responsible for the pod, or -
+// for the default scheduler under --hide-defaults.
+func (o *Options) formatSchedulerName(pod *corev1.Pod) string {
+	if o.HideDefaults && pod.Spec.SchedulerName == corev1.DefaultSchedulerName {
+		return "-"
+	}
+	return valueOrNone(pod.Spec.SchedulerName)
+}
+
+// formatPreemptionPolicy returns the pod's preemption policy, or - for the
+// default PreemptLowerPriority under --hide-defaults.
+func (o *Options) formatPreemptionPolicy(pod *corev1.Pod) string {
+	if pod.Spec.PreemptionPolicy == nil {
+		return "<none>"
+	}
+	if o.HideDefaults && *pod.Spec.PreemptionPolicy == corev1.PreemptLowerPriority {
+		return "-"
+	}
+	return string(*pod.Spec.PreemptionPolicy)
+}
+
+// podScheduledCondition returns the pod's PodScheduled condition, or nil if
+// it has none yet.
+func podScheduledCondition(pod *corev1.Pod) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodScheduled {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
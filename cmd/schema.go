@@ -0,0 +1,38 @@
+package main
+
+// schemas are the values accepted by --schema.
+var schemas = []string{"raw", "v1"}
+
+// PodV1 is the documented, versioned pod schema printed by -o json and
+// -o yaml under --schema v1. Unlike the raw objects it doesn't change with
+// the Kubernetes client version; fields are only ever added to it.
+type PodV1 struct {
+	SchemaVersion string `json:"schemaVersion"`
+	CompactPod
+	Requests map[string]string `json:"requests"`
+	Warnings []string          `json:"_warnings,omitempty"`
+}
+
+// podV1 projects pn onto the v1 schema. Requests are rendered as quantity
+// strings, e.g. "250m" or "128Mi".
+func podV1(pn PodWithWider) PodV1 {
+	requests := map[string]string{}
+	for name, quantity := range pn.Requests {
+		requests[string(name)] = quantity.String()
+	}
+	return PodV1{
+		SchemaVersion: "v1",
+		CompactPod:    compactPod(pn),
+		Requests:      requests,
+		Warnings:      pn.Warnings,
+	}
+}
+
+// projectPod returns what -o json and -o yaml print for pn: the pod with
+// its raw related objects, or its --schema v1 projection.
+func (o *Options) projectPod(pn PodWithWider) interface{} {
+	if o.Schema == "v1" {
+		return podV1(pn)
+	}
+	return pn
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// sortPodNodes orders podNodes according to --sort-by, --sort-by-cpu or
+// --sort-by-memory, or by namespace and name under -A, then reverses the
+// result for --reverse. Ties fall back to namespace and name for a stable
+// order.
+func (o *Options) sortPodNodes(podNodes []PodWithWider) {
+	o.applySort(podNodes)
+
+	if o.Reverse {
+		for i, j := 0, len(podNodes)-1; i < j; i, j = i+1, j-1 {
+			podNodes[i], podNodes[j] = podNodes[j], podNodes[i]
+		}
+	}
+}
+
+func (o *Options) applySort(podNodes []PodWithWider) {
+	var compare func(a, b PodWithWider) int
+
+	switch {
+	case o.SortByCPU:
+		compare = func(a, b PodWithWider) int {
+			return compareResource(b, a, corev1.ResourceCPU)
+		}
+	case o.SortByMemory:
+		compare = func(a, b PodWithWider) int {
+			return compareResource(b, a, corev1.ResourceMemory)
+		}
+	case o.SortBy != "":
+		compare = func(a, b PodWithWider) int {
+			return compareByPath(a, b, o.SortBy)
+		}
+	case o.AllNamespaces:
+		// Like kubectl -A, order by namespace and then name
+		compare = comparePodNames
+	default:
+		return
+	}
+
+	sort.SliceStable(podNodes, func(i, j int) bool {
+		if c := compare(podNodes[i], podNodes[j]); c != 0 {
+			return c < 0
+		}
+		return comparePodNames(podNodes[i], podNodes[j]) < 0
+	})
+}
+
+func compareResource(a, b PodWithWider, name corev1.ResourceName) int {
+	qa := resourceValue(a.Requests, name)
+	qb := resourceValue(b.Requests, name)
+	return qa.Cmp(qb)
+}
+
+// compareByPath compares the values of path in a and b, numerically when
+// both parse as quantities and as strings otherwise.
+func compareByPath(a, b PodWithWider, path string) int {
+	va, errA := getValueByPath(a, path)
+	vb, errB := getValueByPath(b, path)
+	if errA != nil || errB != nil {
+		return 0
+	}
+
+	qa, errA := resource.ParseQuantity(va)
+	qb, errB := resource.ParseQuantity(vb)
+	if errA == nil && errB == nil {
+		return qa.Cmp(qb)
+	}
+
+	switch {
+	case va < vb:
+		return -1
+	case va > vb:
+		return 1
+	}
+	return 0
+}
+
+func comparePodNames(a, b PodWithWider) int {
+	ka := a.Pod.Namespace + "/" + a.Pod.Name
+	kb := b.Pod.Namespace + "/" + b.Pod.Name
+	switch {
+	case ka < kb:
+		return -1
+	case ka > kb:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// spreadBarWidth is the length of the longest bar of the --spread-by
+// histogram; the other bars are scaled to it.
+const spreadBarWidth = 40
+
+// spreadValue returns the value of the topology key on the pod's node.
+func spreadValue(pn PodWithWider, key string) string {
+	if pn.Pod.Spec.NodeName == "" {
+		return "<unscheduled>"
+	}
+	if pn.Node == nil {
+		return pn.missing("nodes")
+	}
+	value, ok := pn.Node.Labels[key]
+	if !ok {
+		return "<unlabeled>"
+	}
+	return value
+}
+
+// printSpread prints how the pods are distributed across the values of the
+// --spread-by topology key, as a histogram. Values found on any node are
+// listed even without pods, so a zone without replicas stands out.
+func (o *Options) printSpread(podNodes []PodWithWider, l *lookups) error {
+	counts := map[string]int{}
+	for _, node := range l.nodes {
+		if value, ok := node.Labels[o.SpreadBy]; ok {
+			counts[value] += 0
+		}
+	}
+	for _, pn := range podNodes {
+		counts[spreadValue(pn, o.SpreadBy)]++
+	}
+
+	// Topology values first, then the placeholders such as <unscheduled>
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		pi, pj := strings.HasPrefix(values[i], "<"), strings.HasPrefix(values[j], "<")
+		if pi != pj {
+			return pj
+		}
+		return values[i] < values[j]
+	})
+
+	most := 0
+	for _, count := range counts {
+		most = max(most, count)
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	if !o.NoHeaders {
+		fmt.Fprintf(w, "%s\tHISTOGRAM\tPODS\n", strings.ToUpper(o.SpreadBy))
+	}
+	for _, value := range values {
+		bar := ""
+		if most > 0 {
+			// Round up, so that a single pod is never drawn as an empty bar
+			bar = strings.Repeat("#", (counts[value]*spreadBarWidth+most-1)/most)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", value, bar, counts[value])
+	}
+	return nil
+}
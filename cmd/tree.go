@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// treeNode is a line in the -o tree output with the lines nested under it.
+type treeNode struct {
+	label    string
+	children []treeNode
+}
+
+// printTree prints the pods as a tree of node → pods → service account and
+// PVCs. Pods that aren't scheduled yet are listed under <unscheduled>,
+// after the nodes.
+func (o *Options) printTree(podNodes []PodWithWider) error {
+	byNode := map[string][]PodWithWider{}
+	var nodes []string
+	for _, pn := range podNodes {
+		node := groupKey(pn, "node")
+		if _, ok := byNode[node]; !ok {
+			nodes = append(nodes, node)
+		}
+		byNode[node] = append(byNode[node], pn)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if (nodes[i] == "<unscheduled>") != (nodes[j] == "<unscheduled>") {
+			return nodes[j] == "<unscheduled>"
+		}
+		return nodes[i] < nodes[j]
+	})
+
+	for _, node := range nodes {
+		root := treeNode{label: node}
+		for _, pn := range byNode[node] {
+			root.children = append(root.children, o.podTreeNode(pn))
+		}
+		fmt.Fprintln(o.Out, root.label)
+		printTreeChildren(o.Out, root.children, "")
+	}
+	return nil
+}
+
+// podTreeNode returns the pod with its service account and PVCs beneath it.
+// The pod is qualified with its namespace under -A.
+func (o *Options) podTreeNode(pn PodWithWider) treeNode {
+	pod := pn.Pod
+	label := pod.Name
+	if o.AllNamespaces {
+		label = pod.Namespace + "/" + pod.Name
+	}
+	node := treeNode{label: fmt.Sprintf("%s (%s)", label, pod.Status.Phase)}
+
+	if pod.Spec.ServiceAccountName != "" {
+		node.children = append(node.children, treeNode{label: "sa: " + pod.Spec.ServiceAccountName})
+	}
+
+	phases := map[string]string{}
+	for _, pvc := range pn.PVCs {
+		phases[pvc.Name] = string(pvc.Status.Phase)
+	}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		label := "pvc: " + vol.PersistentVolumeClaim.ClaimName
+		if phase, ok := phases[vol.PersistentVolumeClaim.ClaimName]; ok {
+			label += " (" + valueOrNone(phase) + ")"
+		}
+		node.children = append(node.children, treeNode{label: label})
+	}
+	return node
+}
+
+// printTreeChildren prints nodes with box-drawing connectors, each line
+// prefixed by the connectors of its ancestors.
+func printTreeChildren(w io.Writer, nodes []treeNode, prefix string) {
+	for i, node := range nodes {
+		connector, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			connector, indent = "└── ", "    "
+		}
+		fmt.Fprintln(w, prefix+connector+node.label)
+		printTreeChildren(w, node.children, prefix+indent)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// minAutoColumnWidth is the narrowest a column is shrunk to when fitting
+// the table to the terminal, so that no value becomes unreadable.
+const minAutoColumnWidth = 12
+
+// columnGap is the padding tabwriter puts between columns.
+const columnGap = 3
+
+// terminalWidth returns the width of the terminal o.Out writes to, or 0 if
+// it isn't a terminal.
+func (o *Options) terminalWidth() int {
+	f, ok := o.Out.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// truncateTable shortens overlong cells of rows with an ellipsis, to
+// --max-column-width when set and otherwise just enough for the table to
+// fit the terminal. Headers are never truncated, so a column is at least
+// as wide as its header. --max-column-width applies to any output, while
+// without it nothing is truncated when the output isn't a terminal. Nothing
+// is truncated under --no-truncate.
+func (o *Options) truncateTable(headers []string, rows [][]string) {
+	if o.NoTruncate {
+		return
+	}
+
+	var limits []int
+	if o.MaxColumnWidth > 0 {
+		limits = make([]int, len(headers))
+		for i := range limits {
+			limits[i] = max(o.MaxColumnWidth, utf8.RuneCountInString(headers[i]))
+		}
+	} else if width := o.terminalWidth(); width > 0 {
+		limits = fitColumns(headers, rows, width)
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			if i < len(limits) {
+				row[i] = truncateCell(row[i], limits[i])
+			}
+		}
+	}
+}
+
+// fitColumns returns the width of each column once the widest ones are
+// shrunk, one character at a time, until the table fits width. Columns
+// aren't shrunk below their header or minAutoColumnWidth, so a table with
+// many columns can still be wider than the terminal.
+func fitColumns(headers []string, rows [][]string, width int) []int {
+	widths := make([]int, len(headers))
+	floors := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = utf8.RuneCountInString(header)
+		floors[i] = max(widths[i], minAutoColumnWidth)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+
+	total := columnGap * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > width {
+		widest := -1
+		for i, w := range widths {
+			if w > floors[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+// truncateCell shortens s to limit characters, ending with an ellipsis.
+func truncateCell(s string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Build information, set at build time with e.g.
+// -ldflags "-X main.pluginVersion=v1.2.3 -X main.gitCommit=abc123 -X main.buildDate=2024-01-01T00:00:00Z"
+var (
+	pluginVersion = "dev"
+	gitCommit     = "unknown"
+	buildDate     = "unknown"
+)
+
+// buildInfo returns the plugin's build information, one field per line.
+func buildInfo() string {
+	return fmt.Sprintf("Plugin version: %s\nGit commit: %s\nBuild date: %s\n", pluginVersion, gitCommit, buildDate)
+}
+
+func NewVersionCommand(opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the plugin's build information and the server version",
+		Long: `Print the plugin version, git commit and build date, plus the Kubernetes version of
+the current cluster when it is reachable. Include it in bug reports.
+
+Examples:
+  kubectl wider version`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.RunVersion()
+		},
+	}
+}
+
+// RunVersion prints the build information followed by the server version.
+// An unreachable cluster only warns, so the build information is always
+// printed.
+func (o *Options) RunVersion() error {
+	fmt.Fprint(o.Out, buildInfo())
+
+	if err := o.Complete(); err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: can't get the server version: %v\n", err)
+		return nil
+	}
+	info, err := o.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: can't get the server version: %v\n", err)
+		return nil
+	}
+	fmt.Fprintf(o.Out, "Server version: %s\n", info.GitVersion)
+	return nil
+}
+
+// minServerVersion is the oldest Kubernetes version serving every API the
+// plugin uses, e.g. autoscaling/v2 and policy/v1.
+var minServerVersion = version.MustParseGeneric("1.23.0")
+
+// checkServerVersion warns when the cluster is older than minServerVersion.
+// It never fails the command, since most columns still work.
+func (o *Options) checkServerVersion() {
+	info, err := o.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: can't check the server version: %v\n", err)
+		return
+	}
+
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: can't parse the server version %q: %v\n", info.GitVersion, err)
+		return
+	}
+
+	if serverVersion.LessThan(minServerVersion) {
+		fmt.Fprintf(o.ErrOut, "Warning: server version %s is older than the minimum supported v%s, some resources may be missing\n", info.GitVersion, minServerVersion)
+	}
+}
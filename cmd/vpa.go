@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// vpaResource is the VerticalPodAutoscaler CRD, queried through the
+// dynamic client since it isn't part of client-go.
+var vpaResource = schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+
+// verticalPodAutoscaler holds the fields of a VerticalPodAutoscaler used to
+// match it to pods and read its recommendation.
+type verticalPodAutoscaler struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		TargetRef *struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"targetRef"`
+	} `json:"spec"`
+	Status struct {
+		Recommendation *struct {
+			ContainerRecommendations []struct {
+				ContainerName string              `json:"containerName"`
+				Target        corev1.ResourceList `json:"target"`
+			} `json:"containerRecommendations"`
+		} `json:"recommendation"`
+	} `json:"status"`
+}
+
+// VPARecommendation compares the requests of a pod's containers with the
+// targets recommended by the VerticalPodAutoscaler of its controller.
+type VPARecommendation struct {
+	Name       string         `json:"name"`
+	Containers []VPAContainer `json:"containers"`
+}
+
+// VPAContainer is a container's current requests next to its recommended
+// ones. Recommended is empty until the VPA has a recommendation.
+type VPAContainer struct {
+	Name        string              `json:"name"`
+	Current     corev1.ResourceList `json:"current"`
+	Recommended corev1.ResourceList `json:"recommended"`
+}
+
+// listVPAs lists the VerticalPodAutoscalers in ns. Clusters without the
+// CRD only get a warning, once per run, so --with-vpa degrades to no
+// recommendations.
+func (o *Options) listVPAs(ctx context.Context, ns string) ([]verticalPodAutoscaler, error) {
+	list, err := withRetry(ctx, o.MaxRetries, func() (*unstructured.UnstructuredList, error) {
+		return o.Dynamic.Resource(vpaResource).Namespace(ns).List(ctx, metav1.ListOptions{})
+	})
+	if apierrors.IsNotFound(err) {
+		if o.vpaCRDWarned {
+			return nil, nil
+		}
+		o.vpaCRDWarned = true
+		fmt.Fprintf(o.ErrOut, "Warning: the VerticalPodAutoscaler CRD (%s) isn't installed, no recommendations are shown\n", vpaResource.GroupResource())
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newResourceError("list", vpaResource.Resource, err)
+	}
+
+	vpas := make([]verticalPodAutoscaler, 0, len(list.Items))
+	for _, item := range list.Items {
+		var vpa verticalPodAutoscaler
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &vpa); err != nil {
+			return nil, fmt.Errorf("invalid VerticalPodAutoscaler %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+		}
+		vpas = append(vpas, vpa)
+	}
+	return vpas, nil
+}
+
+// findVPA returns the recommendation of the VerticalPodAutoscaler whose
+// targetRef is the pod's top-level controller or its direct controller,
+// e.g. a ReplicaSet, or nil if there is none.
+func findVPA(vpas []verticalPodAutoscaler, pod *corev1.Pod, controller *Owner) *VPARecommendation {
+	targets := []Owner{}
+	if controller != nil {
+		targets = append(targets, *controller)
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil {
+		targets = append(targets, Owner{Kind: ref.Kind, Name: ref.Name})
+	}
+
+	for i := range vpas {
+		vpa := &vpas[i]
+		if vpa.Namespace != pod.Namespace || vpa.Spec.TargetRef == nil {
+			continue
+		}
+		for _, target := range targets {
+			if vpa.Spec.TargetRef.Kind == target.Kind && vpa.Spec.TargetRef.Name == target.Name {
+				return recommend(pod, vpa)
+			}
+		}
+	}
+	return nil
+}
+
+// recommend pairs each container's requests with vpa's target for it.
+func recommend(pod *corev1.Pod, vpa *verticalPodAutoscaler) *VPARecommendation {
+	targets := map[string]corev1.ResourceList{}
+	if vpa.Status.Recommendation != nil {
+		for _, rec := range vpa.Status.Recommendation.ContainerRecommendations {
+			targets[rec.ContainerName] = rec.Target
+		}
+	}
+
+	recommendation := &VPARecommendation{Name: vpa.Name}
+	for _, c := range pod.Spec.Containers {
+		recommendation.Containers = append(recommendation.Containers, VPAContainer{
+			Name:        c.Name,
+			Current:     c.Resources.Requests,
+			Recommended: targets[c.Name],
+		})
+	}
+	return recommendation
+}
+
+// formatVPA renders the pod's total CPU and memory requests next to the
+// recommended ones, e.g. "cpu:100m->250m,memory:128Mi->300Mi". Pods whose
+// VPA has no recommendation yet show <pending>.
+func formatVPA(rec *VPARecommendation) string {
+	if rec == nil {
+		return "<none>"
+	}
+
+	current, recommended := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, c := range rec.Containers {
+		addResources(current, c.Current)
+		addResources(recommended, c.Recommended)
+	}
+	if len(recommended) == 0 {
+		return "<pending>"
+	}
+
+	var parts []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		target, ok := recommended[name]
+		if !ok {
+			continue
+		}
+		request := resourceValue(current, name)
+		parts = append(parts, fmt.Sprintf("%s:%s->%s", name, request.String(), target.String()))
+	}
+	return strings.Join(parts, ",")
+}
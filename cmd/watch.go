@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/yaml"
+)
+
+// watchPrinter prints pods one batch at a time as watch events arrive,
+// writing table headers only once.
+type watchPrinter struct {
+	o              *Options
+	w              *tabwriter.Writer
+	paths          []string
+	headers        []string
+	printedHeaders bool
+	printedObjects bool
+}
+
+func (o *Options) newWatchPrinter() (*watchPrinter, error) {
+	p := &watchPrinter{
+		o: o,
+		w: tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0),
+	}
+
+	if o.isCustomColumns() {
+		headers, paths, err := o.parseCustomColumns()
+		if err != nil {
+			return nil, err
+		}
+		p.headers = headers
+		p.paths = paths
+	} else {
+		p.headers = o.tableHeaders()
+	}
+
+	return p, nil
+}
+
+func (p *watchPrinter) print(podNodes []PodWithWider) error {
+	if p.o.OutputFormat == "json" || p.o.OutputFormat == "yaml" {
+		p.o.stripManagedFields(widerObjects(podNodes))
+	}
+
+	switch p.o.OutputFormat {
+	case "json":
+		encoder := json.NewEncoder(p.o.Out)
+		encoder.SetIndent("", "  ")
+		for _, pn := range podNodes {
+			if err := encoder.Encode(p.o.projectPod(pn)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json-compact":
+		encoder := json.NewEncoder(p.o.Out)
+		for _, pn := range podNodes {
+			if err := encoder.Encode(compactPod(pn)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml":
+		for _, pn := range podNodes {
+			data, err := yaml.Marshal(p.o.projectPod(pn))
+			if err != nil {
+				return fmt.Errorf("failed to marshal to YAML: %w", err)
+			}
+			// Separate documents the same way kubectl does when watching
+			if p.printedObjects {
+				fmt.Fprintln(p.o.Out, "---")
+			}
+			fmt.Fprint(p.o.Out, string(data))
+			p.printedObjects = true
+		}
+		return nil
+	}
+
+	if !p.printedHeaders && !p.o.NoHeaders {
+		fmt.Fprintln(p.w, strings.Join(p.headers, "\t"))
+		p.printedHeaders = true
+	}
+
+	for _, pn := range podNodes {
+		if p.paths != nil {
+			fmt.Fprintln(p.w, strings.Join(customColumnsRow(pn, p.paths), "\t"))
+		} else {
+			// Rows arrive one at a time, so only a fixed width can be applied
+			rows := p.o.tableRows(pn)
+			if p.o.MaxColumnWidth > 0 {
+				p.o.truncateTable(p.headers, rows)
+			}
+			for _, row := range rows {
+				fmt.Fprintln(p.w, strings.Join(row, "\t"))
+			}
+		}
+	}
+
+	return p.w.Flush()
+}
+
+// watch prints the initial pods (unless --watch-only is set) and then
+// prints every pod change received after resourceVersion, enriching each
+// pod with the lookups fetched at startup and refreshed every --resync.
+func (o *Options) watch(ctx context.Context, ns, resourceVersion string, initial []PodWithWider, l *lookups) error {
+	printer, err := o.newWatchPrinter()
+	if err != nil {
+		return err
+	}
+
+	if !o.WatchOnly {
+		if err := printer.print(initial); err != nil {
+			return err
+		}
+	}
+
+	watcher, err := withRetry(ctx, o.MaxRetries, func() (watch.Interface, error) {
+		return o.Clientset.CoreV1().Pods(ns).Watch(ctx, metav1.ListOptions{
+			LabelSelector:   o.LabelSelector,
+			FieldSelector:   o.podFieldSelector(),
+			ResourceVersion: resourceVersion,
+		})
+	})
+	if err != nil {
+		return newResourceError("watch", "pods", err)
+	}
+	defer watcher.Stop()
+
+	// A nil channel never fires, which disables resyncing
+	var resync <-chan time.Time
+	if o.Resync > 0 {
+		ticker := time.NewTicker(o.Resync)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resync:
+			l = o.refreshLookups(ctx, ns, l)
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type == watch.Error {
+				return newResourceError("watch", "pods", apierrors.FromObject(event.Object))
+			}
+
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok || !o.keepPod(pod) {
+				continue
+			}
+
+			pn := o.enrichPod(ctx, pod, l)
+			if o.OnlyProblems && !o.hasProblem(pn) {
+				continue
+			}
+			if err := printer.print([]PodWithWider{pn}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// refreshLookups re-lists the related resources so that long watches don't
+// enrich pods with stale nodes, service accounts or PVCs. The node totals
+// of --with-overcommit are kept from startup. If the lists fail, the
+// previous lookups are kept and a warning is printed.
+func (o *Options) refreshLookups(ctx context.Context, ns string, l *lookups) *lookups {
+	refreshed, err := o.fetchLookups(ctx, ns)
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: can't refresh the enrichment, keeping the previous one: %v\n", err)
+		return l
+	}
+	refreshed.nodeResources = l.nodeResources
+	return refreshed
+}
@@ -3,54 +3,277 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/clientcmd"
+	"net/http"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
 )
 
 type PodWithWider struct {
-	Pod            *corev1.Pod
-	Node           *corev1.Node
-	ServiceAccount *corev1.ServiceAccount
-	PVCs           []*corev1.PersistentVolumeClaim
+	Pod                 *corev1.Pod
+	Node                *corev1.Node
+	ServiceAccount      *corev1.ServiceAccount
+	PVCs                []*corev1.PersistentVolumeClaim
+	PVCMounts           []PVCMount                             `json:",omitempty"`
+	PVs                 []*corev1.PersistentVolume             `json:",omitempty"`
+	Controller          *Owner                                 `json:",omitempty"`
+	ReplicaSet          *Owner                                 `json:",omitempty"`
+	HPA                 *autoscalingv2.HorizontalPodAutoscaler `json:",omitempty"`
+	PDBs                []*policyv1.PodDisruptionBudget        `json:",omitempty"`
+	Requests            corev1.ResourceList                    `json:",omitempty"`
+	Limits              corev1.ResourceList                    `json:",omitempty"`
+	Containers          []ContainerSummary                     `json:",omitempty"`
+	InitContainers      []ContainerSummary                     `json:",omitempty"`
+	EphemeralContainers []ContainerSummary                     `json:",omitempty"`
+	Events              []corev1.Event                         `json:",omitempty"`
+	NodeResources       *NodeResources                         `json:",omitempty"`
+	PullSecrets         []PullSecret                           `json:",omitempty"`
+	VPA                 *VPARecommendation                     `json:",omitempty"`
+	Services            []*corev1.Service                      `json:",omitempty"`
+	Ingresses           []*networkingv1.Ingress                `json:",omitempty"`
+
+	// Warnings explains missing enrichment, e.g. a deleted node, under
+	// --annotate-warnings
+	Warnings []string `json:"_warnings,omitempty"`
+
+	// unavailable holds the resources that couldn't be listed, e.g. "nodes",
+	// or fetched for this pod
+	unavailable map[string]bool
+}
+
+// WiderObject is a listed resource extended with related information.
+// PodWithWider is the specialization for pods and WorkloadWithWider the one
+// for deployments, statefulsets and daemonsets; both are printed as json
+// and yaml through printStructured.
+type WiderObject interface {
+	// Objects returns the listed resource followed by the related objects
+	// attached to it.
+	Objects() []metav1.Object
+}
+
+// widerObjects returns items as WiderObjects, for the output paths shared
+// by pods and workloads.
+func widerObjects[T WiderObject](items []T) []WiderObject {
+	objects := make([]WiderObject, 0, len(items))
+	for _, item := range items {
+		objects = append(objects, item)
+	}
+	return objects
+}
+
+// Objects returns the pod and every related object attached to it.
+func (pn PodWithWider) Objects() []metav1.Object {
+	var objects []metav1.Object
+	if pn.Pod != nil {
+		objects = append(objects, pn.Pod)
+	}
+	if pn.Node != nil {
+		objects = append(objects, pn.Node)
+	}
+	if pn.ServiceAccount != nil {
+		objects = append(objects, pn.ServiceAccount)
+	}
+	for _, pvc := range pn.PVCs {
+		objects = append(objects, pvc)
+	}
+	for _, pv := range pn.PVs {
+		if pv != nil {
+			objects = append(objects, pv)
+		}
+	}
+	if pn.HPA != nil {
+		objects = append(objects, pn.HPA)
+	}
+	for _, pdb := range pn.PDBs {
+		objects = append(objects, pdb)
+	}
+	for i := range pn.Events {
+		objects = append(objects, &pn.Events[i])
+	}
+	for _, svc := range pn.Services {
+		objects = append(objects, svc)
+	}
+	for _, ing := range pn.Ingresses {
+		objects = append(objects, ing)
+	}
+	return objects
+}
+
+// PVCMount describes where a pod mounts a persistent volume claim.
+// ReadOnly is true when the volume itself is read-only or every mount of it is.
+type PVCMount struct {
+	ClaimName  string   `json:"claimName"`
+	MountPaths []string `json:"mountPaths"`
+	ReadOnly   bool     `json:"readOnly"`
+}
+
+// ContainerSummary is a condensed view of a container spec and its status.
+type ContainerSummary struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	State string `json:"state"`
 }
 
 type Options struct {
-	Context       string
-	Namespace     string
-	OutputFormat  string
-	LabelSelector string
-	AllNamespaces bool
-	Clientset     *kubernetes.Clientset
-	ConfigFlags   *clientcmd.ClientConfigLoadingRules
+	Context               string
+	Namespace             string
+	OutputFormat          string
+	LabelSelector         string
+	PodIP                 string
+	MinAge                string
+	MaxAge                string
+	AllNamespaces         bool
+	Watch                 bool
+	WatchOnly             bool
+	Resync                time.Duration
+	InsecureSkipTLSVerify bool
+	CertificateAuthority  string
+	WithHPA               bool
+	WithOwners            bool
+	WithPDB               bool
+	ExplainRequests       bool
+	SortBy                string
+	SortByCPU             bool
+	SortByMemory          bool
+	Reverse               bool
+	ShowManagedFields     bool
+	Strict                bool
+	MaxPods               int
+	MaxRetries            int
+	Concurrency           int
+	GroupBy               string
+	WithPV                bool
+	Phase                 string
+	Ready                 bool
+	NotReady              bool
+	Terminating           bool
+	ExcludeSelector       string
+	SelectorFromPod       string
+	SelectorLabels        []string
+	StrictColumns         bool
+	Resource              string
+	NoHeaders             bool
+	MaxColumnWidth        int
+	HideDefaults          bool
+	NoTruncate            bool
+	ShowLabels            bool
+	ShowAnnotations       bool
+	Annotations           []string
+	ExtendedResource      string
+	ServerVersionCheck    bool
+	YAMLStream            bool
+	AnnotateWarnings      bool
+	Schema                string
+	WithOvercommit        bool
+	ByContainer           bool
+	WithEvents            bool
+	WithPullSecrets       bool
+	WithVPA               bool
+	WithIngress           bool
+	Diff                  bool
+	Count                 bool
+	SpreadBy              string
+	OnlyProblems          bool
+	RestartThreshold      int
+	ContextB              string
+	EventsLimit           int
+	Clientset             kubernetes.Interface
+	Metadata              metadata.Interface
+	Dynamic               dynamic.Interface
+	ClientsetB            kubernetes.Interface
+	MetadataB             metadata.Interface
+	DynamicB              dynamic.Interface
+	ConfigFlags           *clientcmd.ClientConfigLoadingRules
+
+	// Out receives the printed pods and ErrOut warnings and errors
+	Out    io.Writer
+	ErrOut io.Writer
+
+	// extendedColumns holds the extended resources shown as columns, set
+	// once the pods are known
+	extendedColumns []corev1.ResourceName
+
+	// outputFromEnv is set when -o wasn't given and OutputFormat comes from
+	// KUBECTL_WIDER_OUTPUT
+	outputFromEnv bool
+
+	// vpaCRDWarned is set once the missing VerticalPodAutoscaler CRD was
+	// reported, so --watch resyncs don't repeat it
+	vpaCRDWarned bool
+
+	// podIPFilter, minAge and maxAge are --pod-ip, --min-age and
+	// --max-age, parsed once by Validate rather than for every pod
+	podIPFilter    *podIPFilter
+	minAge, maxAge *time.Duration
+
+	// excludeSelector is --exclude-selector, parsed once by Validate
+	excludeSelector labels.Selector
 }
 
 func (o *Options) Complete() error {
 	configOverrides := &clientcmd.ConfigOverrides{}
-	
+
 	// Override if context is specified
 	if o.Context != "" {
 		configOverrides.CurrentContext = o.Context
 	}
-	
+
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(o.ConfigFlags, configOverrides)
 
-	config, err := kubeConfig.ClientConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	// Keep injected clients, e.g. fake ones in tests, and build the others.
+	// The metadata and dynamic clients are only needed by some flags.
+	r := o.requirements()
+	var metadataClient *metadata.Interface
+	if r.secrets {
+		metadataClient = &o.Metadata
+	}
+	var dynamicClient *dynamic.Interface
+	if r.vpas {
+		dynamicClient = &o.Dynamic
+	}
+	if err := o.completeClients(kubeConfig, &o.Clientset, metadataClient, dynamicClient); err != nil {
+		return err
 	}
 
-	o.Clientset, err = kubernetes.NewForConfig(config)
-	if err != nil {
-		return fmt.Errorf("failed to create clientset: %w", err)
+	// The --diff counterpart gets the same clients for --context-b
+	if o.Diff {
+		kubeConfigB := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(o.ConfigFlags, &clientcmd.ConfigOverrides{CurrentContext: o.ContextB})
+		var metadataB *metadata.Interface
+		if r.secrets {
+			metadataB = &o.MetadataB
+		}
+		var dynamicB *dynamic.Interface
+		if r.vpas {
+			dynamicB = &o.DynamicB
+		}
+		if err := o.completeClients(kubeConfigB, &o.ClientsetB, metadataB, dynamicB); err != nil {
+			return fmt.Errorf("context %s: %w", o.ContextB, err)
+		}
 	}
 
-	// Get current namespace if not specified
-	if o.Namespace == "" && !o.AllNamespaces {
+	// Get current namespace if not specified, also under -A to look up the
+	// --selector-from-pod pod
+	if o.Namespace == "" && (!o.AllNamespaces || o.SelectorFromPod != "") {
+		var err error
 		o.Namespace, _, err = kubeConfig.Namespace()
 		if err != nil {
 			return fmt.Errorf("failed to get current namespace: %w", err)
@@ -60,9 +283,69 @@ func (o *Options) Complete() error {
 	return nil
 }
 
+// completeClients builds each client that is still nil from kubeConfig.
+// metadataClient and dynamicClient are nil when they aren't needed. The
+// config is only loaded if a client is missing.
+func (o *Options) completeClients(kubeConfig clientcmd.ClientConfig, clientset *kubernetes.Interface, metadataClient *metadata.Interface, dynamicClient *dynamic.Interface) error {
+	missingMetadata := metadataClient != nil && *metadataClient == nil
+	missingDynamic := dynamicClient != nil && *dynamicClient == nil
+	if *clientset != nil && !missingMetadata && !missingDynamic {
+		return nil
+	}
+	config, err := o.restConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	if *clientset == nil {
+		if *clientset, err = kubernetes.NewForConfig(config); err != nil {
+			return fmt.Errorf("failed to create clientset: %w", err)
+		}
+	}
+	if missingMetadata {
+		if *metadataClient, err = metadata.NewForConfig(config); err != nil {
+			return fmt.Errorf("failed to create metadata client: %w", err)
+		}
+	}
+	if missingDynamic {
+		if *dynamicClient, err = dynamic.NewForConfig(config); err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+	}
+	return nil
+}
+
+// restConfig loads the client config of kubeConfig with the TLS flags
+// applied.
+func (o *Options) restConfig(kubeConfig clientcmd.ClientConfig) (*rest.Config, error) {
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	// Override TLS settings if specified, dropping the kubeconfig CA so it
+	// doesn't conflict with the override
+	if o.InsecureSkipTLSVerify {
+		config.TLSClientConfig.Insecure = true
+		config.TLSClientConfig.CAFile = ""
+		config.TLSClientConfig.CAData = nil
+	}
+	if o.CertificateAuthority != "" {
+		config.TLSClientConfig.CAFile = o.CertificateAuthority
+		config.TLSClientConfig.CAData = nil
+	}
+
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &loggingRoundTripper{next: rt}
+	})
+	return config, nil
+}
+
 func NewWiderOptions() *Options {
 	return &Options{
 		ConfigFlags: clientcmd.NewDefaultClientConfigLoadingRules(),
+		Out:         os.Stdout,
+		ErrOut:      os.Stderr,
 	}
 }
 
@@ -91,49 +374,471 @@ Examples:
   # Combine label selector with namespace
   kubectl wider -n default -l app=nginx
 	
+  # Show which nodes each deployment's pods are placed on
+  kubectl wider --resource deployments
+
+  # Wide output with pod and host IPs
+  kubectl wider -o wide
+
+  # Running pods that are not ready, leaving out canaries
+  kubectl wider --phase Running --not-ready --exclude-selector track=canary
+
+  # Pods stuck terminating, with how long they've been terminating
+  kubectl wider -A --terminating -o wide
+
+  # Pods that look like web-7d4b9c, selecting on its app label only
+  kubectl wider --selector-from-pod web-7d4b9c --selector-labels app
+
+  # Only pods with an IP in the given CIDR
+  kubectl wider --pod-ip 10.244.1.0/24
+
+  # Pods created within the last hour, or running for over a week
+  kubectl wider --max-age 1h
+  kubectl wider --min-age 7d
+
+  # Show which HPA scales each pod's controller
+  kubectl wider --with-hpa -o custom-columns=NAME:.pod.metadata.name,CONTROLLER:.controller.name,HPA:.hpa.metadata.name
+
+  # Tell pods from old and new ReplicaSets apart during a rollout
+  kubectl wider -o wide --with-owners
+
+  # GPU requests per pod and allocatable GPUs on its node
+  kubectl wider --extended-resource nvidia.com/gpu
+
+  # Latest event of each pod, e.g. why it is Pending
+  kubectl wider -o wide --with-events
+
+  # Flag pods on nodes whose requests exceed allocatable
+  kubectl wider -o wide --with-overcommit
+
+  # Show which PodDisruptionBudgets cover each pod
+  kubectl wider -o wide --with-pdb
+
+  # Pods using the most CPU or memory requests first
+  kubectl wider -A --sort-by-cpu
+  kubectl wider -A --sort-by-memory
+
+  # Sort by any custom-columns path
+  kubectl wider --sort-by .pod.spec.nodeName
+
+  # The 10 pods with the highest memory requests
+  kubectl wider -A --sort-by-memory --max-pods 10
+
+  # Pods grouped under a heading per node
+  kubectl wider -A --group-by node
+
+  # Oldest pods last instead of first
+  kubectl wider --sort-by .pod.metadata.creationTimestamp --reverse
+
+  # Show the reclaim policy, phase and capacity of bound PVs
+  kubectl wider -o wide --with-pv
+
+  # Self-contained HTML table with labels, e.g. for a ticket
+  kubectl wider -o html --show-labels > pods.html
+
+  # Node → pods → service account and PVCs as a tree
+  kubectl wider -o tree
+
+  # Check that the image pull secrets of each pod exist
+  kubectl wider -o wide --with-pull-secrets
+
+  # Compare requests with the VerticalPodAutoscaler recommendations
+  kubectl wider -o wide --with-vpa
+
+  # Pods missing or different after migrating to another cluster
+  kubectl wider -A --diff --context old-cluster --context-b new-cluster
+
+  # Log every API request with its duration to diagnose a slow run
+  kubectl wider -A -v 4
+
+  # Count the pods matching a selector in every namespace
+  kubectl wider -A -l app=web --count
+
+  # Addresses from which each pod is reachable from outside the cluster
+  kubectl wider -o wide --with-ingress
+
+  # Check that the replicas of a deployment are spread across zones
+  kubectl wider -l app=web --spread-by topology.kubernetes.io/zone
+
+  # Show me what's broken
+  kubectl wider -A -o wide --only-problems
+
+  # Spot pods handled by a custom scheduler
+  kubectl wider -A -o wide --hide-defaults
+
+  # Shorten long cells to 30 characters
+  kubectl wider -o wide --max-column-width 30
+
+  # Claim names of every pod, as a JSON array per pod
+  kubectl wider -o jsonpath-as-json='{.PVCs[*].metadata.name}'
+
+  # Stable, flattened JSON independent of the Kubernetes client version
+  kubectl wider -o json --schema v1
+
+  # Explain missing nodes, service accounts or PVCs in the JSON
+  kubectl wider -o json --annotate-warnings
+
+  # One small JSON object per line, e.g. for dashboards
+  kubectl wider -A -o json-compact
+
+  # Per-container resource audit
+  kubectl wider -A --by-container
+
+  # Show a single annotation as a column
+  kubectl wider --annotation prometheus.io/scrape --annotation prometheus.io/port
+
   # Custom columns output
   kubectl wider -o custom-columns=NAME:.pod.metadata.name,NODE:.node.metadata.name,OS:.node.metadata.labels.kubernetes\.io/os
 	
+  # Custom columns from a file with one NAME:.path spec per line
+  kubectl wider -o custom-columns-file=columns.txt
+
+  # Watch for pod changes after listing
+  kubectl wider -w
+
+  # Watch for pod changes only, without the initial list
+  kubectl wider -w --watch-only
+
+  # Show which API requests would be made, to craft a minimal Role
+  kubectl wider -o yaml --with-hpa --explain-requests
+
+  # Node capacity, allocatable and allocated resources
+  kubectl wider nodes
+
+  # Plugin build and server version, e.g. for bug reports
+  kubectl wider version
+
   # JSON output
   kubectl wider -o json
   
   # YAML output
   kubectl wider -o yaml
 
+  # Set defaults from the environment (explicit flags still win)
+  KUBECTL_WIDER_OUTPUT=wide KUBECTL_WIDER_NAMESPACE=staging kubectl wider
+
   More information is available at the project website:
   https://github.com/boriscosic/wider`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := opts.Complete(); err != nil {
+			outputGiven := cmd.Flags().Changed("output")
+			if err := applyEnvDefaults(cmd.Flags()); err != nil {
 				return err
 			}
+			opts.outputFromEnv = !outputGiven && cmd.Flags().Changed("output")
 			if err := opts.Validate(); err != nil {
 				return err
 			}
-			return opts.Run()
+			if err := opts.Complete(); err != nil {
+				return err
+			}
+			if err := opts.Run(); err != nil {
+				// Keep stderr parseable for scripts consuming json
+				if opts.OutputFormat == "json" || opts.OutputFormat == "json-compact" || opts.isJSONPathAsJSON() {
+					cmd.SilenceErrors = true
+					cmd.SilenceUsage = true
+					printJSONError(opts.ErrOut, err)
+				}
+				return err
+			}
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.Context, "context", "", "", "Context to query (defaults to current context)")
+	cmd.PersistentFlags().StringVarP(&opts.Context, "context", "", "", "Context to query (defaults to current context)")
 	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", "", "Namespace to query (defaults to current context namespace)")
-	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "", "Output format. One of: (json, yaml, custom-columns) (e.g., custom-columns=\"NAME:.pod.metadata.name,NODE:.node.metadata.name,OS:.node.metadata.labels.kubernetes\\.io/os\")")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "", fmt.Sprintf("Output format. One of: (%s) (e.g., custom-columns=\"NAME:.pod.metadata.name,NODE:.node.metadata.name,OS:.node.metadata.labels.kubernetes\\.io/os\")", strings.Join(supportedOutputFormats(), ", ")))
 	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, "Query all namespaces")
 	cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().StringVarP(&opts.ExtendedResource, "extended-resource", "", "", "Add columns with the pod's request and the node's allocatable of an extended resource, e.g. nvidia.com/gpu")
+	cmd.Flags().BoolVarP(&opts.WithEvents, "with-events", "", false, "Attach each pod's most recent events and add an EVENTS column to -o wide")
+	cmd.Flags().BoolVarP(&opts.WithPullSecrets, "with-pull-secrets", "", false, "Check that the image pull secrets of each pod and its service account exist and add a PULL-SECRETS column to -o wide")
+	cmd.Flags().BoolVarP(&opts.WithVPA, "with-vpa", "", false, "Attach the recommendation of the VerticalPodAutoscaler targeting each pod's controller and add a RECOMMENDED column to -o wide")
+	cmd.Flags().BoolVarP(&opts.WithIngress, "with-ingress", "", false, "Attach the Services selecting each pod and the Ingresses routing to them, and add an EXTERNAL column with their external addresses to -o wide")
+	cmd.Flags().BoolVarP(&opts.OnlyProblems, "only-problems", "", false, "Only show pods that aren't Running or Succeeded, restarted more than --restart-threshold times, are terminating or run on a NotReady node")
+	cmd.Flags().IntVarP(&opts.RestartThreshold, "restart-threshold", "", 5, "Restarts above which --only-problems shows a pod")
+	cmd.Flags().StringVarP(&opts.SpreadBy, "spread-by", "", "", "Print how the pods are distributed across the values of a node topology label, e.g. topology.kubernetes.io/zone, as a histogram")
+	cmd.Flags().BoolVarP(&opts.Diff, "diff", "", false, "Compare the pods of --context with those of --context-b and print the ones missing from either or differing in node, image or phase")
+	cmd.Flags().BoolVarP(&opts.Count, "count", "", false, "Only print the number of matching pods, per namespace under -A, without fetching nodes or other related resources")
+	cmd.Flags().StringVarP(&opts.ContextB, "context-b", "", "", "Context compared with --context under --diff")
+	cmd.Flags().IntVarP(&opts.EventsLimit, "events-limit", "", 5, "Maximum number of events attached to each pod with --with-events, 0 for all")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "", 8, "Number of pods enriched in parallel, bounding the fallback requests for objects missing from the lists")
+	cmd.Flags().BoolVarP(&opts.YAMLStream, "yaml-stream", "", false, "With -o yaml, print each pod as a separate document separated by ---")
+	cmd.Flags().BoolVarP(&opts.AnnotateWarnings, "annotate-warnings", "", false, "With -o json or yaml, add a _warnings list to pods whose enrichment is incomplete")
+	cmd.Flags().StringVarP(&opts.Schema, "schema", "", "raw", "Schema of -o json and yaml: raw embeds the Kubernetes objects, v1 is a stable flattened projection")
+	cmd.Flags().BoolVarP(&opts.WithOvercommit, "with-overcommit", "", false, "Sum the requests of all pods on each node and add an OVERCOMMIT column to -o wide flagging nodes requesting more than allocatable")
+	cmd.Flags().BoolVarP(&opts.ByContainer, "by-container", "", false, "Print one row per container, including init containers, with its image, requests, limits and restarts")
+	cmd.Flags().BoolVarP(&opts.NoHeaders, "no-headers", "", false, "Don't print headers in table and html output")
+	cmd.Flags().IntVarP(&opts.MaxColumnWidth, "max-column-width", "", 0, "Truncate table cells longer than this with an ellipsis (default: fit the terminal)")
+	cmd.Flags().BoolVarP(&opts.NoTruncate, "no-truncate", "", false, "Print full values in table output, even wider than the terminal")
+	cmd.Flags().BoolVarP(&opts.HideDefaults, "hide-defaults", "", false, "Show - instead of the default scheduler and preemption policy in -o wide, so custom values stand out")
+	cmd.Flags().BoolVarP(&opts.ShowLabels, "show-labels", "", false, "Show the pod's labels as the last column in table and html output")
+	cmd.Flags().BoolVarP(&opts.ShowAnnotations, "show-annotations", "", false, "Show all of the pod's annotations as an ANNOTATIONS column in table and html output")
+	cmd.Flags().StringArrayVarP(&opts.Annotations, "annotation", "", nil, "Show the value of this annotation key as its own column. Can be repeated")
+	cmd.Flags().StringVarP(&opts.Resource, "resource", "", "pods", "Resource to list: pods, deployments, statefulsets or daemonsets. Workloads show the nodes their pods are placed on")
+	cmd.Flags().StringVarP(&opts.ExcludeSelector, "exclude-selector", "", "", "Selector (label query) of pods to leave out, composable with -l")
+	cmd.Flags().StringVarP(&opts.SelectorFromPod, "selector-from-pod", "", "", "Select pods with the same labels as this pod in the namespace, composable with -l")
+	cmd.Flags().StringSliceVarP(&opts.SelectorLabels, "selector-labels", "", nil, "Label keys of --selector-from-pod to select on (default: all but per-revision and per-instance labels)")
+	cmd.Flags().StringVarP(&opts.Phase, "phase", "", "", "Only show pods in this phase: Running, Pending, Failed or Succeeded")
+	cmd.Flags().BoolVarP(&opts.Ready, "ready", "", false, "Only show pods whose Ready condition is True")
+	cmd.Flags().BoolVarP(&opts.NotReady, "not-ready", "", false, "Only show pods that are not Ready")
+	cmd.Flags().BoolVarP(&opts.Terminating, "terminating", "", false, "Only show pods that are being deleted, e.g. stuck terminating")
+	cmd.Flags().StringVarP(&opts.PodIP, "pod-ip", "", "", "Only show pods with the given IP or an IP within the given CIDR (e.g. 10.244.1.5 or 10.244.0.0/16)")
+	cmd.Flags().StringVarP(&opts.MinAge, "min-age", "", "", "Only show pods at least this old. Accepts Go durations, optionally after days (e.g. 90m, 7d, 2d3h)")
+	cmd.Flags().StringVarP(&opts.MaxAge, "max-age", "", "", "Only show pods at most this old. Accepts Go durations, optionally after days (e.g. 90m, 7d, 2d3h)")
+	cmd.Flags().BoolVarP(&opts.WithOwners, "with-owners", "", false, "Follow each pod's owner chain to its ReplicaSet and controller, and add a REVISION column to -o wide")
+	cmd.Flags().BoolVarP(&opts.WithHPA, "with-hpa", "", false, "Resolve each pod's controller and attach the HorizontalPodAutoscaler targeting it")
+	cmd.Flags().BoolVarP(&opts.WithPDB, "with-pdb", "", false, "Attach the PodDisruptionBudgets covering each pod and add a PDB column to -o wide")
+	cmd.Flags().StringVarP(&opts.SortBy, "sort-by", "", "", "Sort pods by a custom-columns path, e.g. .pod.metadata.name or .requests.cpu")
+	cmd.Flags().BoolVarP(&opts.SortByCPU, "sort-by-cpu", "", false, "Sort pods by total CPU requests, highest first")
+	cmd.Flags().BoolVarP(&opts.SortByMemory, "sort-by-memory", "", false, "Sort pods by total memory requests, highest first")
+	cmd.Flags().BoolVarP(&opts.Reverse, "reverse", "", false, "Reverse the order of the output, with or without --sort-by")
+	cmd.Flags().BoolVarP(&opts.ShowManagedFields, "show-managed-fields", "", false, "If true, keep the managedFields when printing objects in JSON or YAML format")
+	cmd.Flags().IntVarP(&opts.MaxPods, "max-pods", "", 0, "Only show the first N pods after sorting (0 means unlimited)")
+	cmd.PersistentFlags().BoolVarP(&opts.ServerVersionCheck, "server-version-check", "", false, "Warn if the cluster is older than the oldest Kubernetes version supported by the plugin")
+	cmd.PersistentFlags().IntVarP(&opts.MaxRetries, "max-retries", "", 3, "Retry API requests failing with transient errors (network, 429, 5xx) up to this many times")
+	cmd.Flags().StringVarP(&opts.GroupBy, "group-by", "", "", "Group table output under a heading per node, namespace, serviceaccount or owner-kind")
+	cmd.Flags().BoolVarP(&opts.WithPV, "with-pv", "", false, "Attach the PersistentVolumes bound to each pod's PVCs and add PV columns to -o wide")
+	cmd.Flags().BoolVarP(&opts.StrictColumns, "strict-columns", "", false, "Fail instead of warning when a custom column is empty for every pod")
+	cmd.Flags().BoolVarP(&opts.Strict, "strict", "", false, "Fail when nodes, service accounts or PVCs can't be listed instead of warning and showing <unknown>")
+	cmd.Flags().BoolVarP(&opts.ExplainRequests, "explain-requests", "", false, "Print the API requests the other flags would trigger instead of running them")
+	cmd.PersistentFlags().BoolVarP(&opts.InsecureSkipTLSVerify, "insecure-skip-tls-verify", "", false, "If true, the server's certificate will not be checked for validity")
+	cmd.PersistentFlags().StringVarP(&opts.CertificateAuthority, "certificate-authority", "", "", "Path to a cert file for the certificate authority")
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "After listing the requested pods, watch for changes")
+	cmd.Flags().BoolVarP(&opts.WatchOnly, "watch-only", "", false, "Watch for changes to the requested pods without listing them first (requires --watch)")
+	cmd.Flags().DurationVarP(&opts.Resync, "resync", "", 10*time.Minute, "With --watch, re-list nodes, service accounts, PVCs and the other related resources this often (0 disables)")
+
+	cmd.AddCommand(NewNodesCommand(opts))
+	cmd.AddCommand(NewVersionCommand(opts))
+
+	// --version only prints the build information, without contacting the
+	// cluster
+	cmd.Version = pluginVersion
+	cmd.SetVersionTemplate(buildInfo())
+	addLogFlags(cmd.PersistentFlags())
 
 	return cmd
 }
 
 func (o *Options) Validate() error {
-	if o.OutputFormat != "" {
-		isValid := false
+	if o.InsecureSkipTLSVerify && o.CertificateAuthority != "" {
+		return fmt.Errorf("--insecure-skip-tls-verify and --certificate-authority are mutually exclusive")
+	}
+
+	if o.PodIP != "" {
+		filter, err := parsePodIPFilter(o.PodIP)
+		if err != nil {
+			return err
+		}
+		o.podIPFilter = filter
+	}
+
+	if o.MinAge != "" {
+		d, err := parseAge(o.MinAge)
+		if err != nil {
+			return fmt.Errorf("invalid --min-age: %w", err)
+		}
+		o.minAge = &d
+	}
+	if o.MaxAge != "" {
+		d, err := parseAge(o.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid --max-age: %w", err)
+		}
+		o.maxAge = &d
+	}
+	if o.minAge != nil && o.maxAge != nil && *o.minAge > *o.maxAge {
+		return fmt.Errorf("--min-age (%s) must not be greater than --max-age (%s)", o.MinAge, o.MaxAge)
+	}
+
+	if o.Phase != "" {
+		valid := false
+		for _, phase := range podPhases {
+			if o.Phase == string(phase) {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unsupported --phase: %s (supported: Running, Pending, Failed, Succeeded)", o.Phase)
+		}
+	}
+
+	if o.Ready && o.NotReady {
+		return fmt.Errorf("--ready and --not-ready are mutually exclusive")
+	}
+
+	if o.ExcludeSelector != "" {
+		selector, err := labels.Parse(o.ExcludeSelector)
+		if err != nil {
+			return fmt.Errorf("invalid --exclude-selector: %w", err)
+		}
+		o.excludeSelector = selector
+	}
+
+	if len(o.SelectorLabels) > 0 && o.SelectorFromPod == "" {
+		return fmt.Errorf("--selector-labels requires --selector-from-pod")
+	}
+	if o.SelectorFromPod != "" && o.isWorkloadResource() {
+		return fmt.Errorf("--selector-from-pod is only supported for --resource pods")
+	}
+
+	sortFlags := 0
+	for _, set := range []bool{o.SortBy != "", o.SortByCPU, o.SortByMemory} {
+		if set {
+			sortFlags++
+		}
+	}
+	if sortFlags > 1 {
+		return fmt.Errorf("--sort-by, --sort-by-cpu and --sort-by-memory are mutually exclusive")
+	}
+
+	if o.ExtendedResource != "" && !isExtendedResourceName(o.ExtendedResource) {
+		return fmt.Errorf("invalid --extended-resource: %s (expected a domain-prefixed name such as nvidia.com/gpu)", o.ExtendedResource)
+	}
+
+	if o.ByContainer && o.OutputFormat != "" && o.OutputFormat != "wide" && o.OutputFormat != "html" {
+		return fmt.Errorf("--by-container is only supported for the default, wide and html output")
+	}
+
+	if o.YAMLStream && o.OutputFormat != "yaml" {
+		return fmt.Errorf("--yaml-stream requires -o yaml")
+	}
+
+	if o.AnnotateWarnings && o.OutputFormat != "json" && o.OutputFormat != "yaml" {
+		return fmt.Errorf("--annotate-warnings requires -o json or -o yaml")
+	}
+
+	if o.Schema != "" && !slices.Contains(schemas, o.Schema) {
+		return fmt.Errorf("unsupported --schema: %s (supported: %s)", o.Schema, strings.Join(schemas, ", "))
+	}
+	if o.Schema == "v1" && ((o.OutputFormat != "json" && o.OutputFormat != "yaml") || o.isWorkloadResource()) {
+		return fmt.Errorf("--schema v1 requires -o json or -o yaml with --resource pods")
+	}
 
-		if o.OutputFormat == "json" || o.OutputFormat == "yaml" {
-			isValid = true
-		} else if strings.HasPrefix(o.OutputFormat, "custom-columns=") {
-			isValid = true
+	if o.StrictColumns && !o.isCustomColumns() {
+		return fmt.Errorf("--strict-columns is only supported for custom-columns output")
+	}
+
+	if o.GroupBy != "" {
+		if _, ok := groupByKeys[o.GroupBy]; !ok {
+			return fmt.Errorf("unsupported --group-by: %s (supported: node, namespace, serviceaccount, owner-kind)", o.GroupBy)
+		}
+		if o.OutputFormat != "" && o.OutputFormat != "wide" {
+			return fmt.Errorf("--group-by is only supported for the default and wide output")
 		}
+		if o.Watch {
+			return fmt.Errorf("--group-by can't be used with --watch")
+		}
+	}
+
+	if o.OutputFormat == "html" && o.Watch {
+		return fmt.Errorf("html output can't be used with --watch")
+	}
+
+	if o.OutputFormat == "tree" && o.Watch {
+		return fmt.Errorf("tree output can't be used with --watch")
+	}
 
-		if !isValid {
-			return fmt.Errorf("unsupported output format: %s (supported: json, yaml, custom-columns=...)", o.OutputFormat)
+	if o.isJSONPathAsJSON() && o.Watch {
+		return fmt.Errorf("jsonpath-as-json output can't be used with --watch")
+	}
+
+	if o.Diff {
+		if o.ContextB == "" {
+			return fmt.Errorf("--diff requires --context-b")
+		}
+		if o.outputGiven() && o.OutputFormat != "json" {
+			return fmt.Errorf("--diff is only supported for the default and json output")
+		}
+		if o.Watch || o.isWorkloadResource() {
+			return fmt.Errorf("--diff can't be used with --watch or workload resources")
+		}
+	} else if o.ContextB != "" {
+		return fmt.Errorf("--context-b requires --diff")
+	}
+
+	if o.Count {
+		if o.outputGiven() {
+			return fmt.Errorf("--count can't be used with -o")
+		}
+		if o.Watch || o.Diff || o.isWorkloadResource() {
+			return fmt.Errorf("--count can't be used with --watch, --diff or workload resources")
+		}
+	}
+
+	if o.SpreadBy != "" {
+		if o.outputGiven() {
+			return fmt.Errorf("--spread-by can't be used with -o")
+		}
+		if o.Watch || o.Diff || o.Count || o.GroupBy != "" || o.isWorkloadResource() {
+			return fmt.Errorf("--spread-by can't be used with --watch, --diff, --count, --group-by or workload resources")
+		}
+	}
+
+	if o.OnlyProblems && (o.Count || o.isWorkloadResource()) {
+		return fmt.Errorf("--only-problems can't be used with --count or workload resources")
+	}
+	if o.RestartThreshold < 0 {
+		return fmt.Errorf("--restart-threshold must not be negative")
+	}
+
+	if o.MaxRetries < 0 {
+		return fmt.Errorf("--max-retries must not be negative")
+	}
+
+	if o.MaxColumnWidth < 0 {
+		return fmt.Errorf("--max-column-width must not be negative")
+	}
+	if o.MaxColumnWidth > 0 && o.NoTruncate {
+		return fmt.Errorf("--max-column-width and --no-truncate are mutually exclusive")
+	}
+
+	if o.Concurrency < 0 {
+		return fmt.Errorf("--concurrency must not be negative")
+	}
+
+	if o.EventsLimit < 0 {
+		return fmt.Errorf("--events-limit must not be negative")
+	}
+
+	if o.MaxPods < 0 {
+		return fmt.Errorf("--max-pods must not be negative")
+	}
+
+	if o.WatchOnly && !o.Watch {
+		return fmt.Errorf("--watch-only requires --watch (-w)")
+	}
+
+	if o.Resync < 0 {
+		return fmt.Errorf("--resync must not be negative")
+	}
+
+	if o.Resource != "" {
+		if _, ok := workloadResources[o.Resource]; !ok {
+			return fmt.Errorf("unsupported --resource: %s (supported: pods, deployments, statefulsets, daemonsets)", o.Resource)
+		}
+	}
+	if o.isWorkloadResource() {
+		if o.isCustomColumns() || o.isJSONPathAsJSON() || o.OutputFormat == "html" || o.OutputFormat == "tree" || o.OutputFormat == "json-compact" {
+			return fmt.Errorf("custom-columns, jsonpath-as-json, html, tree and json-compact output are only supported for --resource pods")
+		}
+		if o.Watch || o.GroupBy != "" || o.ByContainer || o.SortBy != "" || o.SortByCPU || o.SortByMemory {
+			return fmt.Errorf("--watch, --group-by, --by-container and the sort flags are only supported for --resource pods")
+		}
+	}
+
+	if o.OutputFormat != "" {
+		if !isSupportedOutputFormat(o.OutputFormat) {
+			return &outputFormatError{format: o.OutputFormat, supported: supportedOutputFormats()}
+		}
+		if o.isCustomColumns() {
+			if _, _, err := o.parseCustomColumns(); err != nil {
+				return err
+			}
+		}
+		if o.isJSONPathAsJSON() {
+			if _, err := o.parseJSONPath(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -142,132 +847,613 @@ func (o *Options) Validate() error {
 func (o *Options) Run() error {
 	ctx := context.Background()
 
+	if o.ExplainRequests {
+		return o.printPlan()
+	}
+
 	// Set namespace for API call
 	ns := o.Namespace
 	if o.AllNamespaces {
 		ns = ""
 	}
 
-	needsSA := false
-	needsPVC := false
+	if o.ServerVersionCheck {
+		o.checkServerVersion()
+	}
+
+	// Each context checks its namespace and --selector-from-pod pod
+	if o.Diff {
+		return o.runDiff(ctx, ns)
+	}
+
+	if err := o.checkNamespace(ctx); err != nil {
+		return err
+	}
+
+	if o.SelectorFromPod != "" {
+		if err := o.applySelectorFromPod(ctx); err != nil {
+			return err
+		}
+	}
+
+	if o.isWorkloadResource() {
+		return o.RunWorkloads(ctx, ns)
+	}
+
+	if o.Count {
+		return o.runCount(ctx, ns)
+	}
+
+	podNodes, lookups, resourceVersion, err := o.collectPods(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	if o.SpreadBy != "" {
+		return o.printSpread(podNodes, lookups)
+	}
+
+	o.sortPodNodes(podNodes)
+	o.extendedColumns = o.extendedResourceColumns(podNodes)
+
+	// Bound the displayed rows after sorting
+	if o.MaxPods > 0 && len(podNodes) > o.MaxPods {
+		if o.OutputFormat != "json" && o.OutputFormat != "json-compact" && o.OutputFormat != "yaml" && !o.isJSONPathAsJSON() {
+			fmt.Fprintf(o.ErrOut, "(showing %d of %d)\n", o.MaxPods, len(podNodes))
+		}
+		podNodes = podNodes[:o.MaxPods]
+	}
+
+	if o.Watch {
+		return o.watch(ctx, ns, resourceVersion, podNodes, lookups)
+	}
+
+	return o.print(podNodes)
+}
+
+// collectPods fetches the lookups, lists the pods in ns and enriches those
+// kept by the filters. It also returns the lookups and the list's resource
+// version, from which a watch resumes.
+func (o *Options) collectPods(ctx context.Context, ns string) ([]PodWithWider, *lookups, string, error) {
+	start := time.Now()
+	lookups, err := o.fetchLookups(ctx, ns)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	klog.V(logRequests).Infof("fetched the lookups in %s", time.Since(start))
 
-	if o.OutputFormat == "json" || o.OutputFormat == "yaml" {
-		needsPVC = true
-		needsSA = true
+	pods, err := o.listPods(ctx, ns)
+	if err != nil {
+		return nil, nil, "", err
 	}
 
-	nodeMap := make(map[string]*corev1.Node)
-	saMap := make(map[string]*corev1.ServiceAccount)
-	pvcMap := make(map[string]*corev1.PersistentVolumeClaim)
+	// Sum the requests on every node once, before annotating the pods
+	if o.WithOvercommit && !lookups.unavailable["nodes"] {
+		lookups.nodeResources, err = o.nodeResources(ctx, lookups, pods)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
 
-	if strings.Contains(o.OutputFormat, ".sa") || strings.Contains(o.OutputFormat, ".serviceAccount") {
-		needsSA = true
+	// Build pod with node information, unless only changes are wanted
+	var podNodes []PodWithWider
+	if !o.WatchOnly {
+		var kept []*corev1.Pod
+		for i := range pods.Items {
+			if o.keepPod(&pods.Items[i]) {
+				kept = append(kept, &pods.Items[i])
+			}
+		}
+		start = time.Now()
+		podNodes = o.enrichPods(ctx, kept, lookups)
+		klog.V(logRequests).Infof("enriched %d pods in %s", len(podNodes), time.Since(start))
 	}
 
-	if strings.Contains(o.OutputFormat, ".pvc") || strings.Contains(o.OutputFormat, ".pvcs") {
-		needsPVC = true
+	// Node readiness is only known once the pods are enriched
+	if o.OnlyProblems {
+		podNodes = o.keepProblems(podNodes)
 	}
+	return podNodes, lookups, pods.ResourceVersion, nil
+}
 
-	// Get pods
-	pods, err := o.Clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+// listPods lists the pods in ns matching the label selector and, when the
+// server supports it, the field selector.
+func (o *Options) listPods(ctx context.Context, ns string) (*corev1.PodList, error) {
+	listOptions := metav1.ListOptions{
 		LabelSelector: o.LabelSelector,
+		FieldSelector: o.podFieldSelector(),
+	}
+	klog.V(logSelectors).Infof("listing pods in namespace %q with label selector %q and field selector %q", ns, listOptions.LabelSelector, listOptions.FieldSelector)
+	pods, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PodList, error) {
+		return o.Clientset.CoreV1().Pods(ns).List(ctx, listOptions)
 	})
+	if err != nil && listOptions.FieldSelector != "" && apierrors.IsBadRequest(err) {
+		// Fall back to filtering by phase client-side in keepPod
+		listOptions.FieldSelector = ""
+		pods, err = withRetry(ctx, o.MaxRetries, func() (*corev1.PodList, error) {
+			return o.Clientset.CoreV1().Pods(ns).List(ctx, listOptions)
+		})
+	}
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+		return nil, newResourceError("list", "pods", err)
+	}
+	return pods, nil
+}
+
+func (o *Options) print(podNodes []PodWithWider) error {
+	if o.isCustomColumns() {
+		return o.printCustomColumns(podNodes)
+	} else if o.OutputFormat == "json" {
+		return o.printStructured(widerObjects(podNodes))
+	} else if o.isJSONPathAsJSON() {
+		return o.printJSONPathAsJSON(podNodes)
+	} else if o.OutputFormat == "json-compact" {
+		return o.printJSONCompact(podNodes)
+	} else if o.OutputFormat == "yaml" {
+		return o.printStructured(widerObjects(podNodes))
+	} else if o.OutputFormat == "html" {
+		return o.printHTML(podNodes)
+	} else if o.OutputFormat == "tree" {
+		return o.printTree(podNodes)
+	} else if o.GroupBy != "" {
+		return o.printGrouped(podNodes)
+	}
+
+	return o.printDefault(podNodes)
+}
+
+// lookups holds the related resources fetched once per run and used to
+// enrich every pod.
+type lookups struct {
+	nodes           map[string]*corev1.Node
+	serviceAccounts map[string]*corev1.ServiceAccount
+	pvcs            map[string]*corev1.PersistentVolumeClaim
+	pvs             map[string]*corev1.PersistentVolume
+	replicaSets     map[string]*appsv1.ReplicaSet
+	hpas            []autoscalingv2.HorizontalPodAutoscaler
+	pdbs            []policyv1.PodDisruptionBudget
+	events          map[string][]corev1.Event
+	secrets         map[string]bool
+	vpas            []verticalPodAutoscaler
+	services        []corev1.Service
+	ingresses       []networkingv1.Ingress
+	nodeResources   map[string]*NodeResources
+	ownersResolved  bool
+	unavailable     map[string]bool
+}
+
+// lookupFailed records that resource could not be listed and warns about
+// it, so pods are still printed with that enrichment shown as <unknown>.
+// Under --strict the error is returned instead.
+func (o *Options) lookupFailed(l *lookups, resource string, err error) error {
+	err = newResourceError("list", resource, err)
+	if o.Strict {
+		return err
+	}
+
+	fmt.Fprintf(o.ErrOut, "Warning: %v (showing <unknown>, use --strict to fail instead)\n", err)
+	l.unavailable[resource] = true
+	return nil
+}
+
+func (o *Options) fetchLookups(ctx context.Context, ns string) (*lookups, error) {
+	r := o.requirements()
+
+	l := &lookups{
+		nodes:           make(map[string]*corev1.Node),
+		serviceAccounts: make(map[string]*corev1.ServiceAccount),
+		pvcs:            make(map[string]*corev1.PersistentVolumeClaim),
+		replicaSets:     make(map[string]*appsv1.ReplicaSet),
+		unavailable:     make(map[string]bool),
 	}
 
 	// Get nodes
-	nodes, err := o.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := withRetry(ctx, o.MaxRetries, func() (*corev1.NodeList, error) {
+		return o.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to list nodes: %w", err)
+		if err := o.lookupFailed(l, "nodes", err); err != nil {
+			return nil, err
+		}
+	} else {
+		// Create node map for quick lookup
+		for i := range nodes.Items {
+			l.nodes[nodes.Items[i].Name] = &nodes.Items[i]
+		}
 	}
 
-	// Create node map for quick lookup
-	for i := range nodes.Items {
-		nodeMap[nodes.Items[i].Name] = &nodes.Items[i]
+	if r.pvcs {
+		// Get all PVCs if needed
+		allPVCs, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PersistentVolumeClaimList, error) {
+			return o.Clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			if err := o.lookupFailed(l, "persistentvolumeclaims", err); err != nil {
+				return nil, err
+			}
+		} else {
+			// Create PVC map for quick lookup (namespace/name -> PVC)
+			for i := range allPVCs.Items {
+				key := allPVCs.Items[i].Namespace + "/" + allPVCs.Items[i].Name
+				l.pvcs[key] = &allPVCs.Items[i]
+			}
+		}
 	}
 
-	if needsPVC {
-		// Get all PVCs if needed
-		allPVCs, err := o.Clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+	if r.pvs {
+		// Get all PVs to follow bound claims
+		allPVs, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PersistentVolumeList, error) {
+			return o.Clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+		})
 		if err != nil {
-			return fmt.Errorf("failed to list PVCs: %w", err)
+			return nil, newResourceError("list", "persistentvolumes", err)
 		}
 
-		// Create PVC map for quick lookup (namespace/name -> PVC)
-		for i := range allPVCs.Items {
-			key := allPVCs.Items[i].Namespace + "/" + allPVCs.Items[i].Name
-			pvcMap[key] = &allPVCs.Items[i]
+		// Create PV map for quick lookup (name -> PV)
+		l.pvs = make(map[string]*corev1.PersistentVolume)
+		for i := range allPVs.Items {
+			l.pvs[allPVs.Items[i].Name] = &allPVs.Items[i]
 		}
 	}
 
-	if needsSA {
+	if r.serviceAccounts {
 		// Get all ServiceAccounts if needed
-		allSAs, err := o.Clientset.CoreV1().ServiceAccounts(ns).List(ctx, metav1.ListOptions{})
+		allSAs, err := withRetry(ctx, o.MaxRetries, func() (*corev1.ServiceAccountList, error) {
+			return o.Clientset.CoreV1().ServiceAccounts(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			if err := o.lookupFailed(l, "serviceaccounts", err); err != nil {
+				return nil, err
+			}
+		} else {
+			// Create ServiceAccount map for quick lookup (namespace/name -> SA)
+			for i := range allSAs.Items {
+				key := allSAs.Items[i].Namespace + "/" + allSAs.Items[i].Name
+				l.serviceAccounts[key] = &allSAs.Items[i]
+			}
+		}
+	}
+
+	if r.replicaSets {
+		// Get ReplicaSets to resolve the owner chain
+		allRSs, err := withRetry(ctx, o.MaxRetries, func() (*appsv1.ReplicaSetList, error) {
+			return o.Clientset.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{})
+		})
 		if err != nil {
-			return fmt.Errorf("failed to list ServiceAccounts: %w", err)
+			return nil, newResourceError("list", "replicasets", err)
 		}
 
-		// Create ServiceAccount map for quick lookup (namespace/name -> SA)
-		for i := range allSAs.Items {
-			key := allSAs.Items[i].Namespace + "/" + allSAs.Items[i].Name
-			saMap[key] = &allSAs.Items[i]
+		// Create ReplicaSet map for quick lookup (namespace/name -> RS)
+		for i := range allRSs.Items {
+			key := allRSs.Items[i].Namespace + "/" + allRSs.Items[i].Name
+			l.replicaSets[key] = &allRSs.Items[i]
 		}
+		l.ownersResolved = true
 	}
 
-	// Build pod with node information
-	var podNodes []PodWithWider
-	for i := range pods.Items {
-		pod := &pods.Items[i]
-		node := nodeMap[pod.Spec.NodeName]
-
-		// Get ServiceAccount
-		var sa *corev1.ServiceAccount
-		if pod.Spec.ServiceAccountName != "" && len(saMap) > 0 {
-			saKey := pod.Namespace + "/" + pod.Spec.ServiceAccountName
-			sa = saMap[saKey]
-			// If not in map, try to fetch it directly
-			if sa == nil {
-				fetchedSA, err := o.Clientset.CoreV1().ServiceAccounts(pod.Namespace).Get(ctx, pod.Spec.ServiceAccountName, metav1.GetOptions{})
-				if err == nil {
-					sa = fetchedSA
-				}
+	if r.hpas {
+		allHPAs, err := withRetry(ctx, o.MaxRetries, func() (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+			return o.Clientset.AutoscalingV2().HorizontalPodAutoscalers(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "horizontalpodautoscalers", err)
+		}
+		l.hpas = allHPAs.Items
+	}
+
+	if r.pdbs {
+		allPDBs, err := withRetry(ctx, o.MaxRetries, func() (*policyv1.PodDisruptionBudgetList, error) {
+			return o.Clientset.PolicyV1().PodDisruptionBudgets(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "poddisruptionbudgets", err)
+		}
+		l.pdbs = allPDBs.Items
+	}
+
+	if r.services {
+		allServices, err := withRetry(ctx, o.MaxRetries, func() (*corev1.ServiceList, error) {
+			return o.Clientset.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "services", err)
+		}
+		l.services = allServices.Items
+	}
+
+	if r.ingresses {
+		allIngresses, err := withRetry(ctx, o.MaxRetries, func() (*networkingv1.IngressList, error) {
+			return o.Clientset.NetworkingV1().Ingresses(ns).List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "ingresses", err)
+		}
+		l.ingresses = allIngresses.Items
+	}
+
+	if r.events {
+		allEvents, err := withRetry(ctx, o.MaxRetries, func() (*corev1.EventList, error) {
+			return o.Clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=Pod",
+			})
+		})
+		if err != nil {
+			return nil, newResourceError("list", "events", err)
+		}
+		l.events = groupPodEvents(allEvents.Items, o.EventsLimit)
+	}
+
+	if r.secrets {
+		l.secrets, err = o.listSecretNames(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.vpas {
+		l.vpas, err = o.listVPAs(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// enrichPods enriches pods with up to --concurrency workers, since the
+// fallback Gets for objects missing from the lookups are made per pod. The
+// workers share the clientset and so its rate limiter. Results keep the
+// order of pods, and 0 or 1 enriches them one at a time.
+func (o *Options) enrichPods(ctx context.Context, pods []*corev1.Pod, l *lookups) []PodWithWider {
+	podNodes := make([]PodWithWider, len(pods))
+	workers := min(max(o.Concurrency, 1), len(pods))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				podNodes[i] = o.enrichPod(ctx, pods[i], l)
+			}
+		}()
+	}
+	for i := range pods {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return podNodes
+}
+
+func (o *Options) enrichPod(ctx context.Context, pod *corev1.Pod, l *lookups) PodWithWider {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		if o.AnnotateWarnings {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		}
+	}
+
+	unavailable := l.unavailable
+	node := l.nodes[pod.Spec.NodeName]
+	if pod.Spec.NodeName != "" && !l.unavailable["nodes"] {
+		logLookup("node", pod.Spec.NodeName, node != nil)
+	}
+	// Under --watch the node may have joined since the nodes were listed
+	if node == nil && pod.Spec.NodeName != "" && !l.unavailable["nodes"] && o.Watch {
+		fetchedNode, err := withRetry(ctx, o.MaxRetries, func() (*corev1.Node, error) {
+			return o.Clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		})
+		if err == nil {
+			node = fetchedNode
+		} else if !apierrors.IsNotFound(err) {
+			warn("node %s fetch failed: %v", pod.Spec.NodeName, err)
+			unavailable = withUnavailable(unavailable, "nodes")
+		}
+	}
+	if node == nil && pod.Spec.NodeName != "" {
+		if l.unavailable["nodes"] {
+			warn("nodes could not be listed")
+		} else if !unavailable["nodes"] {
+			warn("node %s not found", pod.Spec.NodeName)
+		}
+	}
+
+	// Get ServiceAccount
+	var sa *corev1.ServiceAccount
+	if pod.Spec.ServiceAccountName != "" && l.unavailable["serviceaccounts"] {
+		warn("serviceaccounts could not be listed")
+	}
+	if pod.Spec.ServiceAccountName != "" && len(l.serviceAccounts) > 0 {
+		saKey := pod.Namespace + "/" + pod.Spec.ServiceAccountName
+		sa = l.serviceAccounts[saKey]
+		logLookup("serviceaccount", saKey, sa != nil)
+		// If not in map, try to fetch it directly
+		if sa == nil {
+			fetchedSA, err := withRetry(ctx, o.MaxRetries, func() (*corev1.ServiceAccount, error) {
+				return o.Clientset.CoreV1().ServiceAccounts(pod.Namespace).Get(ctx, pod.Spec.ServiceAccountName, metav1.GetOptions{})
+			})
+			if err == nil {
+				sa = fetchedSA
+			} else {
+				warn("serviceaccount %s fetch failed: %v", pod.Spec.ServiceAccountName, err)
+				unavailable = withUnavailable(unavailable, "serviceaccounts")
 			}
 		}
+	}
 
-		// Get PVCs for this pod
-		var podPVCs []*corev1.PersistentVolumeClaim
-		for _, vol := range pod.Spec.Volumes {
-			if vol.PersistentVolumeClaim != nil && len(pvcMap) > 0 {
-				pvcKey := pod.Namespace + "/" + vol.PersistentVolumeClaim.ClaimName
-				if pvc, ok := pvcMap[pvcKey]; ok {
-					podPVCs = append(podPVCs, pvc)
+	// Get PVCs for this pod
+	var podPVCs []*corev1.PersistentVolumeClaim
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil && l.unavailable["persistentvolumeclaims"] {
+			warn("persistentvolumeclaims could not be listed, %s is missing", vol.PersistentVolumeClaim.ClaimName)
+		}
+		if vol.PersistentVolumeClaim != nil && len(l.pvcs) > 0 {
+			pvcKey := pod.Namespace + "/" + vol.PersistentVolumeClaim.ClaimName
+			pvc, ok := l.pvcs[pvcKey]
+			logLookup("persistentvolumeclaim", pvcKey, ok)
+			if ok {
+				podPVCs = append(podPVCs, pvc)
+			} else {
+				// If not in map, try to fetch it directly
+				fetchedPVC, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PersistentVolumeClaim, error) {
+					return o.Clientset.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, vol.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+				})
+				if err == nil {
+					podPVCs = append(podPVCs, fetchedPVC)
 				} else {
-					// If not in map, try to fetch it directly
-					fetchedPVC, err := o.Clientset.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, vol.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
-					if err == nil {
-						podPVCs = append(podPVCs, fetchedPVC)
-					}
+					warn("persistentvolumeclaim %s fetch failed: %v", vol.PersistentVolumeClaim.ClaimName, err)
 				}
 			}
 		}
+	}
 
-		podNodes = append(podNodes, PodWithWider{
-			Pod:            pod,
-			Node:           node,
-			ServiceAccount: sa,
-			PVCs:           podPVCs,
-		})
+	// Follow bound claims to their PVs
+	var pvs []*corev1.PersistentVolume
+	if l.pvs != nil {
+		pvs = boundPVs(podPVCs, l.pvs)
+		for i, pvc := range podPVCs {
+			if pvs[i] == nil && pvc.Status.Phase == corev1.ClaimBound && pvc.Spec.VolumeName != "" {
+				warn("persistentvolume %s of claim %s not found", pvc.Spec.VolumeName, pvc.Name)
+			}
+		}
 	}
 
-	// Output
-	if strings.HasPrefix(o.OutputFormat, "custom-columns=") {
-		return o.printCustomColumns(podNodes)
-	} else if o.OutputFormat == "json" {
-		return o.printJSON(podNodes)
-	} else if o.OutputFormat == "yaml" {
-		return o.printYAML(podNodes)
+	// Check the pull secrets of the pod and its service account
+	var pullSecrets []PullSecret
+	if l.secrets != nil {
+		pullSecrets = resolvePullSecrets(pod, sa, l.secrets)
 	}
 
-	return o.printDefault(podNodes)
+	// Resolve the owner chain and the autoscalers targeting the controller
+	var controller, replicaSet *Owner
+	var hpa *autoscalingv2.HorizontalPodAutoscaler
+	var vpa *VPARecommendation
+	if l.ownersResolved {
+		controller = resolveController(pod, l.replicaSets)
+		replicaSet = resolveReplicaSet(pod, l.replicaSets)
+		hpa = findHPA(l.hpas, pod.Namespace, controller)
+		vpa = findVPA(l.vpas, pod, controller)
+	}
+
+	// Follow the Services selecting the pod to the Ingresses routing to them
+	var services []*corev1.Service
+	var ingresses []*networkingv1.Ingress
+	if o.WithIngress {
+		services = matchingServices(pod, l.services)
+		ingresses = matchingIngresses(services, l.ingresses)
+	}
+
+	// Summarize containers
+	var ephemeral []corev1.Container
+	for _, ec := range pod.Spec.EphemeralContainers {
+		ephemeral = append(ephemeral, corev1.Container(ec.EphemeralContainerCommon))
+	}
+
+	return PodWithWider{
+		Pod:                 pod,
+		Node:                node,
+		ServiceAccount:      sa,
+		PVCs:                podPVCs,
+		PVCMounts:           pvcMounts(pod),
+		PVs:                 pvs,
+		Controller:          controller,
+		ReplicaSet:          replicaSet,
+		HPA:                 hpa,
+		PDBs:                matchingPDBs(pod, l.pdbs),
+		Requests:            podRequests(pod),
+		Limits:              podLimits(pod),
+		unavailable:         unavailable,
+		Containers:          summarizeContainers(pod.Spec.Containers, pod.Status.ContainerStatuses),
+		InitContainers:      summarizeContainers(pod.Spec.InitContainers, pod.Status.InitContainerStatuses),
+		EphemeralContainers: summarizeContainers(ephemeral, pod.Status.EphemeralContainerStatuses),
+		Events:              l.events[pod.Namespace+"/"+pod.Name],
+		NodeResources:       l.nodeResources[pod.Spec.NodeName],
+		PullSecrets:         pullSecrets,
+		VPA:                 vpa,
+		Services:            services,
+		Ingresses:           ingresses,
+		Warnings:            warnings,
+	}
+}
+
+// withUnavailable returns a copy of unavailable with resource added, so a
+// fetch failing for one pod doesn't mark the resource for the others.
+func withUnavailable(unavailable map[string]bool, resource string) map[string]bool {
+	marked := make(map[string]bool, len(unavailable)+1)
+	for r := range unavailable {
+		marked[r] = true
+	}
+	marked[resource] = true
+	return marked
+}
+
+// pvcMounts correlates the pod's PVC volumes with the volumeMounts of all of
+// its containers, including init containers.
+func pvcMounts(pod *corev1.Pod) []PVCMount {
+	var containers []corev1.Container
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	var mounts []PVCMount
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		mount := PVCMount{
+			ClaimName: vol.PersistentVolumeClaim.ClaimName,
+			ReadOnly:  vol.PersistentVolumeClaim.ReadOnly,
+		}
+		allReadOnly := true
+		seen := map[string]bool{}
+		for _, c := range containers {
+			for _, vm := range c.VolumeMounts {
+				if vm.Name != vol.Name {
+					continue
+				}
+				if !vm.ReadOnly {
+					allReadOnly = false
+				}
+				if !seen[vm.MountPath] {
+					seen[vm.MountPath] = true
+					mount.MountPaths = append(mount.MountPaths, vm.MountPath)
+				}
+			}
+		}
+		if len(mount.MountPaths) > 0 && allReadOnly {
+			mount.ReadOnly = true
+		}
+
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+func summarizeContainers(containers []corev1.Container, statuses []corev1.ContainerStatus) []ContainerSummary {
+	var summaries []ContainerSummary
+	for _, c := range containers {
+		state := "Unknown"
+		for _, cs := range statuses {
+			if cs.Name != c.Name {
+				continue
+			}
+			switch {
+			case cs.State.Running != nil:
+				state = "Running"
+			case cs.State.Waiting != nil:
+				state = "Waiting"
+			case cs.State.Terminated != nil:
+				state = "Terminated"
+			}
+		}
+		summaries = append(summaries, ContainerSummary{
+			Name:  c.Name,
+			Image: c.Image,
+			State: state,
+		})
+	}
+	return summaries
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// workloadResources are the values accepted by --resource and the kind
+// each one lists.
+var workloadResources = map[string]string{
+	"pods":         "Pod",
+	"deployments":  "Deployment",
+	"statefulsets": "StatefulSet",
+	"daemonsets":   "DaemonSet",
+}
+
+// WorkloadWithWider is a deployment, statefulset or daemonset extended with
+// the nodes its pods are placed on.
+type WorkloadWithWider struct {
+	Kind      string
+	Workload  metav1.Object
+	Ready     int32
+	Desired   int32
+	Placement []NodePlacement
+
+	selector labels.Selector
+}
+
+// NodePlacement counts a workload's pods on a single node.
+type NodePlacement struct {
+	Node string `json:"node"`
+	Pods int    `json:"pods"`
+}
+
+// Objects returns the workload itself.
+func (wl WorkloadWithWider) Objects() []metav1.Object {
+	return []metav1.Object{wl.Workload}
+}
+
+// isWorkloadResource reports whether --resource selects a workload kind
+// instead of pods.
+func (o *Options) isWorkloadResource() bool {
+	return o.Resource != "" && o.Resource != "pods"
+}
+
+// listWorkloads lists the workloads selected by --resource in ns.
+func (o *Options) listWorkloads(ctx context.Context, ns string) ([]WorkloadWithWider, error) {
+	listOptions := metav1.ListOptions{LabelSelector: o.LabelSelector}
+
+	var workloads []WorkloadWithWider
+	add := func(obj metav1.Object, selector *metav1.LabelSelector, ready, desired int32) error {
+		sel, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector on %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+		workloads = append(workloads, WorkloadWithWider{
+			Kind:     workloadResources[o.Resource],
+			Workload: obj,
+			Ready:    ready,
+			Desired:  desired,
+			selector: sel,
+		})
+		return nil
+	}
+
+	switch o.Resource {
+	case "deployments":
+		list, err := withRetry(ctx, o.MaxRetries, func() (*appsv1.DeploymentList, error) {
+			return o.Clientset.AppsV1().Deployments(ns).List(ctx, listOptions)
+		})
+		if err != nil {
+			return nil, newResourceError("list", o.Resource, err)
+		}
+		for i := range list.Items {
+			d := &list.Items[i]
+			desired := int32(1)
+			if d.Spec.Replicas != nil {
+				desired = *d.Spec.Replicas
+			}
+			if err := add(d, d.Spec.Selector, d.Status.ReadyReplicas, desired); err != nil {
+				return nil, err
+			}
+		}
+	case "statefulsets":
+		list, err := withRetry(ctx, o.MaxRetries, func() (*appsv1.StatefulSetList, error) {
+			return o.Clientset.AppsV1().StatefulSets(ns).List(ctx, listOptions)
+		})
+		if err != nil {
+			return nil, newResourceError("list", o.Resource, err)
+		}
+		for i := range list.Items {
+			sts := &list.Items[i]
+			desired := int32(1)
+			if sts.Spec.Replicas != nil {
+				desired = *sts.Spec.Replicas
+			}
+			if err := add(sts, sts.Spec.Selector, sts.Status.ReadyReplicas, desired); err != nil {
+				return nil, err
+			}
+		}
+	case "daemonsets":
+		list, err := withRetry(ctx, o.MaxRetries, func() (*appsv1.DaemonSetList, error) {
+			return o.Clientset.AppsV1().DaemonSets(ns).List(ctx, listOptions)
+		})
+		if err != nil {
+			return nil, newResourceError("list", o.Resource, err)
+		}
+		for i := range list.Items {
+			ds := &list.Items[i]
+			if err := add(ds, ds.Spec.Selector, ds.Status.NumberReady, ds.Status.DesiredNumberScheduled); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return workloads, nil
+}
+
+// placeWorkloads counts, per node, the pods each workload's selector
+// matches in the workload's namespace. Unscheduled pods are not counted.
+func placeWorkloads(workloads []WorkloadWithWider, pods []corev1.Pod) {
+	for i := range workloads {
+		wl := &workloads[i]
+		counts := map[string]int{}
+		for j := range pods {
+			pod := &pods[j]
+			if pod.Namespace != wl.Workload.GetNamespace() || pod.Spec.NodeName == "" {
+				continue
+			}
+			if wl.selector.Empty() || !wl.selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			counts[pod.Spec.NodeName]++
+		}
+
+		wl.Placement = nil
+		for node, count := range counts {
+			wl.Placement = append(wl.Placement, NodePlacement{Node: node, Pods: count})
+		}
+		sort.Slice(wl.Placement, func(a, b int) bool {
+			return wl.Placement[a].Node < wl.Placement[b].Node
+		})
+	}
+}
+
+// RunWorkloads lists the workloads selected by --resource and places them
+// on nodes using the pods in ns.
+func (o *Options) RunWorkloads(ctx context.Context, ns string) error {
+	workloads, err := o.listWorkloads(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	pods, err := withRetry(ctx, o.MaxRetries, func() (*corev1.PodList, error) {
+		return o.Clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return newResourceError("list", "pods", err)
+	}
+
+	// Only pods passing the client-side filters are placed
+	var kept []corev1.Pod
+	for i := range pods.Items {
+		if o.keepPod(&pods.Items[i]) {
+			kept = append(kept, pods.Items[i])
+		}
+	}
+	placeWorkloads(workloads, kept)
+
+	sort.SliceStable(workloads, func(i, j int) bool {
+		a, b := workloads[i].Workload, workloads[j].Workload
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+
+	if o.MaxPods > 0 && len(workloads) > o.MaxPods {
+		if o.OutputFormat != "json" && o.OutputFormat != "yaml" {
+			fmt.Fprintf(o.ErrOut, "(showing %d of %d)\n", o.MaxPods, len(workloads))
+		}
+		workloads = workloads[:o.MaxPods]
+	}
+
+	return o.printWorkloads(workloads)
+}
+
+func (o *Options) printWorkloads(workloads []WorkloadWithWider) error {
+	if o.OutputFormat == "json" || o.OutputFormat == "yaml" {
+		return o.printStructured(widerObjects(workloads))
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	headers := []string{"NAME", "KIND", "READY", "PODS", "NODES"}
+	if o.AllNamespaces {
+		headers = append([]string{"NAMESPACE"}, headers...)
+	}
+	if !o.NoHeaders {
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+
+	for _, wl := range workloads {
+		row := []string{
+			wl.Workload.GetName(),
+			wl.Kind,
+			fmt.Sprintf("%d/%d", wl.Ready, wl.Desired),
+			fmt.Sprintf("%d", placedPods(wl.Placement)),
+			formatPlacement(wl.Placement),
+		}
+		if o.AllNamespaces {
+			row = append([]string{wl.Workload.GetNamespace()}, row...)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return nil
+}
+
+// placedPods returns the number of pods across all nodes.
+func placedPods(placement []NodePlacement) int {
+	total := 0
+	for _, p := range placement {
+		total += p.Pods
+	}
+	return total
+}
+
+// formatPlacement renders placement as "node(pods)" entries joined with
+// commas, or <none> when no pod is scheduled.
+func formatPlacement(placement []NodePlacement) string {
+	if len(placement) == 0 {
+		return "<none>"
+	}
+
+	var entries []string
+	for _, p := range placement {
+		entries = append(entries, fmt.Sprintf("%s(%d)", p.Node, p.Pods))
+	}
+	return strings.Join(entries, ",")
+}